@@ -0,0 +1,70 @@
+// Package discovery finds the used accounts of a wallet by walking the derivation paths of the layouts used by
+// wallets. The chain packages tell whether an account is used, the walk is the same for all of them.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/icodeface/hdkeyring"
+	"golang.org/x/xerrors"
+)
+
+// DefaultGapLimit is the number of consecutive unused accounts ending the walk of a scheme, as in BIP-44.
+const DefaultGapLimit = 20
+
+// PathScheme is a derivation path layout used by wallets. Format has a single %d for the walked index.
+type PathScheme struct {
+	Name   string
+	Format string
+}
+
+// Path returns the derivation path of the account at index.
+func (s PathScheme) Path(index int) string {
+	return fmt.Sprintf(s.Format, index)
+}
+
+// Account is a used account found by Walk.
+type Account struct {
+	Scheme string
+	Path   string
+	// Address is the address of the account in the text format of its chain.
+	Address string
+}
+
+// UsedFunc derives the account at path and returns its address and whether it is used on chain.
+type UsedFunc func(ctx context.Context, path hdkeyring.DerivationPath) (address string, used bool, err error)
+
+// Walk returns the used accounts of the schemes, by scheme and index. For each scheme it walks the indexes until
+// gapLimit consecutive accounts are unused. Schemes share paths, e.g. their first account, which are checked once
+// and reported with the first scheme.
+func Walk(ctx context.Context, schemes []PathScheme, gapLimit int, used UsedFunc) ([]*Account, error) {
+	var found []*Account
+	checked := map[string]bool{}
+	for _, scheme := range schemes {
+		for index, gap := 0, 0; gap < gapLimit; index++ {
+			path := scheme.Path(index)
+			isUsed, ok := checked[path]
+			if !ok {
+				derivationPath, err := hdkeyring.ParseDerivationPath(path)
+				if err != nil {
+					return nil, xerrors.Errorf("scheme %s: %w", scheme.Name, err)
+				}
+				address, u, err := used(ctx, derivationPath)
+				if err != nil {
+					return nil, xerrors.Errorf("check %s: %w", path, err)
+				}
+				isUsed = u
+				checked[path] = isUsed
+				if isUsed {
+					found = append(found, &Account{Scheme: scheme.Name, Path: path, Address: address})
+				}
+			}
+			if isUsed {
+				gap = 0
+			} else {
+				gap++
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,58 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/icodeface/hdkeyring"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	bip44 := PathScheme{Name: "bip44", Format: "m/44'/60'/0'/0/%d"}
+	accounts := PathScheme{Name: "accounts", Format: "m/44'/60'/%d'/0/0"}
+	key := func(path hdkeyring.DerivationPath) string {
+		return fmt.Sprint([]uint32(path))
+	}
+	usedPaths := map[string]bool{}
+	for _, path := range []string{"m/44'/60'/0'/0/0", "m/44'/60'/0'/0/3", "m/44'/60'/2'/0/0", "m/44'/60'/0'/0/7"} {
+		usedPaths[key(hdkeyring.MustParseDerivationPath(path))] = true
+	}
+	checks := map[string]int{}
+	used := func(ctx context.Context, path hdkeyring.DerivationPath) (string, bool, error) {
+		checks[key(path)]++
+		return "address " + key(path), usedPaths[key(path)], nil
+	}
+
+	found, err := Walk(context.Background(), []PathScheme{bip44, accounts}, 3, used)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// index 7 is after a gap of 3 unused accounts
+	expected := []Account{
+		{"bip44", "m/44'/60'/0'/0/0", ""},
+		{"bip44", "m/44'/60'/0'/0/3", ""},
+		{"accounts", "m/44'/60'/2'/0/0", ""},
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %d accounts, got %d", len(expected), len(found))
+	}
+	for i, account := range found {
+		expected[i].Address = "address " + key(hdkeyring.MustParseDerivationPath(expected[i].Path))
+		if *account != expected[i] {
+			t.Errorf("account %d: got %+v", i, account)
+		}
+	}
+	// the first account of both schemes is checked once
+	if checks[key(hdkeyring.MustParseDerivationPath("m/44'/60'/0'/0/0"))] != 1 || len(checks) != 7+5 {
+		t.Errorf("wrong checks %v", checks)
+	}
+
+	failure := errors.New("rate limited")
+	_, err = Walk(context.Background(), []PathScheme{bip44}, 3, func(ctx context.Context, path hdkeyring.DerivationPath) (string, bool, error) {
+		return "", false, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected the check error, got %v", err)
+	}
+}
@@ -1,28 +1,253 @@
 package ethereum
 
 import (
+	"context"
 	"crypto/ecdsa"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/xerrors"
 	"math/big"
+	"time"
 )
 
 type Account struct {
 	PrivateKey *ecdsa.PrivateKey
 	Address    common.Address
+
+	// NonceManager, if set, hands out the nonces of Transfer and Transact, so concurrent sends from the account don't collide.
+	// Otherwise the pending nonce of the node is used.
+	NonceManager *NonceManager
+	// FeeEstimator, if set, prices the transactions of the account. Otherwise the node suggestions are used.
+	FeeEstimator FeeEstimator
+	// Signer, if set, signs for the account instead of PrivateKey, which may then be nil.
+	Signer Signer
+}
+
+// AccountFromPrivateKey creates an account from a secp256k1 private key.
+func AccountFromPrivateKey(pk *ecdsa.PrivateKey) *Account {
+	return &Account{
+		PrivateKey: pk,
+		Address:    crypto.PubkeyToAddress(pk.PublicKey),
+	}
 }
 
 func (account *Account) SignTransaction(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
-	signer := types.NewEIP155Signer(chainID)
-	// Sign the transaction and verify the sender to avoid hardware fault surprises
-	signedTx, err := types.SignTx(tx, signer, account.PrivateKey)
+	return account.SignTransactionContext(context.Background(), tx, chainID)
+}
+
+// SignTransactionContext is SignTransaction with a context for the Signer of the account.
+func (account *Account) SignTransactionContext(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	// The latest signer accepts legacy (EIP-155), access list and EIP-1559 transactions
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+	sig, err := account.signer().SignDigest(ctx, hash[:])
 	if err != nil {
 		return nil, err
 	}
-
+	signedTx, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, err
+	}
+	// Verify the sender to avoid hardware fault surprises
+	sender, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return nil, err
+	}
+	if sender != account.Address {
+		return nil, xerrors.Errorf("transaction signed by %s instead of %s", sender.Hex(), account.Address.Hex())
+	}
 	return signedTx, nil
 }
 
-func (account *Account) Transfer() {
-	// todo
+// NewTransactOpts returns transaction options for contract bindings signed by the account.
+// Fees come from the FeeEstimator of the account, or the node suggestions, as for Transfer. The gas limit is left
+// empty, so bindings estimate it. The nonce is left empty too and comes from the node; use Transact to take it
+// from the NonceManager.
+func (account *Account) NewTransactOpts(ctx context.Context, client *Client) (*bind.TransactOpts, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get chain id: %w", err)
+	}
+	fees, err := account.suggestFees(ctx, client, FeeNormal)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.TransactOpts{
+		From: account.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != account.Address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return account.SignTransactionContext(ctx, tx, chainID)
+		},
+		Context: ctx,
+	}
+	if fees.GasTipCap == nil {
+		opts.GasPrice = fees.GasFeeCap
+	} else {
+		opts.GasTipCap, opts.GasFeeCap = fees.GasTipCap, fees.GasFeeCap
+	}
+	return opts, nil
+}
+
+// Transfer sends amount wei to the address to, with EIP-1559 fees on London chains and a legacy gas price otherwise.
+func (account *Account) Transfer(client *Client, to string, amount *big.Int) (*types.Transaction, error) {
+	if !ValidateAddress(to) {
+		return nil, errors.New("invalid address")
+	}
+	if amount.Sign() <= 0 {
+		return nil, errors.New("invalid value")
+	}
+	toAddr := common.HexToAddress(to)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get chain id: %w", err)
+	}
+	gas, err := client.EstimateGas(ctx, ethereum.CallMsg{From: account.Address, To: &toAddr, Value: amount})
+	if err != nil {
+		return nil, xerrors.Errorf("estimate gas: %w", err)
+	}
+	fees, err := account.suggestFees(ctx, client, FeeNormal)
+	if err != nil {
+		return nil, err
+	}
+	gasTipCap, gasFeeCap := fees.GasTipCap, fees.GasFeeCap
+
+	balance, err := client.PendingBalanceAt(ctx, account.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("get balance: %w", err)
+	}
+	cost := new(big.Int).Add(amount, new(big.Int).Mul(gasFeeCap, new(big.Int).SetUint64(gas)))
+	if balance.Cmp(cost) < 0 {
+		return nil, xerrors.Errorf("not enough funds: %s < %s", balance, cost)
+	}
+
+	return account.sendTransaction(ctx, client, chainID, func(nonce uint64) types.TxData {
+		if gasTipCap == nil {
+			return &types.LegacyTx{Nonce: nonce, GasPrice: gasFeeCap, Gas: gas, To: &toAddr, Value: amount}
+		}
+		return &types.DynamicFeeTx{ChainID: chainID, Nonce: nonce, GasTipCap: gasTipCap, GasFeeCap: gasFeeCap, Gas: gas, To: &toAddr, Value: amount}
+	})
+}
+
+// suggestFees prices a transaction with the FeeEstimator of the account, or with the node suggestions.
+func (account *Account) suggestFees(ctx context.Context, client *Client, speed FeeSpeed) (*FeeEstimate, error) {
+	if account.FeeEstimator != nil {
+		return account.FeeEstimator.EstimateFees(ctx, speed)
+	}
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("get head: %w", err)
+	}
+	if head.BaseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("suggest gas price: %w", err)
+		}
+		return &FeeEstimate{Legacy: true, GasFeeCap: gasPrice}, nil
+	}
+	gasTipCap, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("suggest gas tip cap: %w", err)
+	}
+	// leaves room for the base fee to double
+	gasFeeCap := new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	return &FeeEstimate{BaseFee: head.BaseFee, GasTipCap: gasTipCap, GasFeeCap: gasFeeCap}, nil
+}
+
+// sendTransaction signs and broadcasts the transaction built by txData with the next nonce of the account.
+// With a NonceManager, it is built again with a new nonce when the previous one turns out to be used.
+func (account *Account) sendTransaction(ctx context.Context, client *Client, chainID *big.Int, txData func(nonce uint64) types.TxData) (*types.Transaction, error) {
+	return account.withNonce(ctx, client, func(nonce uint64) (*types.Transaction, error) {
+		signed, err := account.SignTransactionContext(ctx, types.NewTx(txData(nonce)), chainID)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.SendTransaction(ctx, signed); err != nil {
+			return signed, xerrors.Errorf("send transaction: %w", err)
+		}
+		return signed, nil
+	})
+}
+
+// Transact sends the transaction of a contract binding call with options from NewTransactOpts. With a NonceManager,
+// the nonce comes from the manager and the call is made again with a new nonce when the previous one turns out to be used.
+func (account *Account) Transact(ctx context.Context, client *Client, call func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	if account.NonceManager == nil {
+		opts, err := account.NewTransactOpts(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		return call(opts)
+	}
+	return account.withNonce(ctx, client, func(nonce uint64) (*types.Transaction, error) {
+		opts, err := account.NewTransactOpts(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		// bindings broadcast right after signing, so an error after signing is a send error
+		var signed *types.Transaction
+		sign := opts.Signer
+		opts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			tx, err := sign(address, tx)
+			if err == nil {
+				signed = tx
+			}
+			return tx, err
+		}
+		tx, err := call(opts)
+		if err != nil {
+			return signed, err
+		}
+		return tx, nil
+	})
+}
+
+// withNonce calls send with the next nonce of the account. send returns the transaction it signed, nil if it failed
+// before signing, and the error of the broadcast. With a NonceManager, the nonce is released when send fails before
+// signing, and send is called again with a new nonce when the previous one turns out to be used.
+func (account *Account) withNonce(ctx context.Context, client *Client, send func(nonce uint64) (*types.Transaction, error)) (*types.Transaction, error) {
+	if account.NonceManager == nil {
+		nonce, err := client.PendingNonceAt(ctx, account.Address)
+		if err != nil {
+			return nil, xerrors.Errorf("get pending nonce: %w", err)
+		}
+		signed, err := send(nonce)
+		if err != nil {
+			return nil, err
+		}
+		return signed, nil
+	}
+
+	nonces := account.NonceManager
+	for attempt := 0; attempt < 3; attempt++ {
+		nonce, err := nonces.Acquire(ctx, account.Address)
+		if err != nil {
+			return nil, err
+		}
+		signed, sendErr := send(nonce)
+		if sendErr == nil {
+			return signed, nonces.Sent(account.Address, nonce, signed.Hash())
+		}
+		if signed == nil {
+			_ = nonces.Release(account.Address, nonce)
+			return nil, sendErr
+		}
+		retry, err := nonces.HandleSendError(ctx, account.Address, nonce, signed.Hash(), sendErr)
+		if err != nil {
+			return nil, err
+		}
+		if !retry {
+			return signed, nil
+		}
+	}
+	return nil, errors.New("send transaction: nonce still used after 3 attempts")
 }
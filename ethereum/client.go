@@ -9,9 +9,15 @@ import (
 type Client = ethclient.Client
 
 func NewClient(ctx context.Context, rawurl string) (*Client, error) {
+	client, _, err := NewClientWithRPC(ctx, rawurl)
+	return client, err
+}
+
+// NewClientWithRPC returns a client and the rpc client under it, for the methods Client doesn't wrap like eth_feeHistory.
+func NewClientWithRPC(ctx context.Context, rawurl string) (*Client, *rpc.Client, error) {
 	c, err := rpc.DialContext(ctx, rawurl)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return ethclient.NewClient(c), nil
+	return ethclient.NewClient(c), c, nil
 }
@@ -0,0 +1,67 @@
+package ethereum
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/icodeface/chain-kit/discovery"
+	"github.com/icodeface/hdkeyring"
+)
+
+// PathScheme is a derivation path layout used by wallets.
+type PathScheme = discovery.PathScheme
+
+// DiscoveredAccount is a used address found by Discovery, with the checksummed hex address.
+type DiscoveredAccount = discovery.Account
+
+var (
+	// SchemeBIP44 is used by MetaMask, Trezor and DerivePath(0, i).
+	SchemeBIP44 = PathScheme{Name: "bip44", Format: "m/44'/60'/0'/0/%d"}
+	// SchemeLedgerLive creates one account per hardened index.
+	SchemeLedgerLive = PathScheme{Name: "ledger-live", Format: "m/44'/60'/%d'/0/0"}
+	// SchemeLedgerLegacy is used by MyEtherWallet and the legacy Ledger Chrome app.
+	SchemeLedgerLegacy = PathScheme{Name: "ledger-legacy", Format: "m/44'/60'/0'/%d"}
+)
+
+// Discovery finds the used addresses of a wallet. For each scheme it walks the indexes until GapLimit
+// consecutive addresses have neither balance nor sent transactions.
+type Discovery struct {
+	GapLimit int
+	Schemes  []PathScheme
+
+	client *Client
+}
+
+func NewDiscovery(client *Client) *Discovery {
+	return &Discovery{
+		GapLimit: discovery.DefaultGapLimit,
+		Schemes:  []PathScheme{SchemeBIP44, SchemeLedgerLive, SchemeLedgerLegacy},
+		client:   client,
+	}
+}
+
+// Discover returns the used addresses of the wallet, by scheme and index.
+func (d *Discovery) Discover(ctx context.Context, wallet *Wallet) ([]*DiscoveredAccount, error) {
+	return discovery.Walk(ctx, d.Schemes, d.GapLimit, func(ctx context.Context, path hdkeyring.DerivationPath) (string, bool, error) {
+		account, err := wallet.DeriveAccount(path)
+		if err != nil {
+			return "", false, err
+		}
+		used, err := d.used(ctx, account.Address)
+		return account.Address.Hex(), used, err
+	})
+}
+
+func (d *Discovery) used(ctx context.Context, address common.Address) (bool, error) {
+	nonce, err := d.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		return false, err
+	}
+	if nonce > 0 {
+		return true, nil
+	}
+	balance, err := d.client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return false, err
+	}
+	return balance.Sign() > 0, nil
+}
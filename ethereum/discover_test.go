@@ -0,0 +1,51 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscovery_Used(t *testing.T) {
+	sent := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	funded := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		addr := common.HexToAddress(req.Params[0].(string))
+		var result interface{}
+		switch req.Method {
+		case "eth_getTransactionCount":
+			result = hexutil.Uint64(0)
+			if addr == sent {
+				result = hexutil.Uint64(3)
+			}
+		case "eth_getBalance":
+			result = "0x0"
+			if addr == funded {
+				result = "0xde0b6b3a7640000"
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discovery := NewDiscovery(client)
+	for addr, expected := range map[common.Address]bool{sent: true, funded: true, {3}: false} {
+		if used, err := discovery.used(context.Background(), addr); err != nil || used != expected {
+			t.Errorf("%s: expected used %v, got %v %v", addr.Hex(), expected, used, err)
+		}
+	}
+}
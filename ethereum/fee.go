@@ -0,0 +1,170 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/xerrors"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// FeeSpeed selects how fast a transaction should be included.
+type FeeSpeed int
+
+const (
+	FeeSlow FeeSpeed = iota
+	FeeNormal
+	FeeFast
+)
+
+// ErrFeeCapExceeded is returned when the projected base fee is above the configured maximum fee cap.
+var ErrFeeCapExceeded = errors.New("base fee above the maximum fee cap")
+
+// FeeEstimate is the fees to pay for a transaction.
+type FeeEstimate struct {
+	// Legacy is set on pre-London chains, GasFeeCap is then the gas price and GasTipCap is nil.
+	Legacy bool
+	// BaseFee is the projected base fee of the next block, nil on pre-London chains.
+	BaseFee   *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// FeeEstimator prices transactions. Account uses it when set, instead of the node suggestions.
+type FeeEstimator interface {
+	EstimateFees(ctx context.Context, speed FeeSpeed) (*FeeEstimate, error)
+}
+
+// FeeOracle estimates fees from the priority fees paid in recent blocks, with eth_feeHistory.
+type FeeOracle struct {
+	client *rpc.Client
+
+	// Blocks is the number of recent blocks sampled.
+	Blocks uint64
+	// Percentiles are the priority fee percentiles of slow, normal and fast transactions.
+	Percentiles [3]float64
+	// BaseFeeMultiplier scales the projected base fee in the fee cap, so the transaction stays valid while the
+	// base fee rises. 2 survives 6 consecutive full blocks.
+	BaseFeeMultiplier int64
+	// MaxFeeCap, if set, caps the fee cap (or the gas price on pre-London chains).
+	MaxFeeCap *big.Int
+}
+
+// NewFeeOracle creates a fee oracle querying the node with client, the rpc client returned by NewClientWithRPC.
+func NewFeeOracle(client *rpc.Client) *FeeOracle {
+	return &FeeOracle{
+		client:            client,
+		Blocks:            20,
+		Percentiles:       [3]float64{10, 50, 90},
+		BaseFeeMultiplier: 2,
+	}
+}
+
+type feeHistory struct {
+	OldestBlock   *hexutil.Big     `json:"oldestBlock"`
+	BaseFeePerGas []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64        `json:"gasUsedRatio"`
+	Reward        [][]*hexutil.Big `json:"reward"`
+}
+
+// EstimateFees returns the fees for speed. The tip is the median of the speed percentile over the sampled blocks,
+// the fee cap is the tip plus BaseFeeMultiplier times the next base fee.
+// Pre-London chains and nodes without eth_feeHistory get eth_gasPrice, scaled by speed.
+func (o *FeeOracle) EstimateFees(ctx context.Context, speed FeeSpeed) (*FeeEstimate, error) {
+	if speed < FeeSlow || speed > FeeFast {
+		return nil, xerrors.Errorf("invalid fee speed %d", speed)
+	}
+	var history feeHistory
+	err := o.client.CallContext(ctx, &history, "eth_feeHistory", hexutil.Uint64(o.Blocks), "latest", o.Percentiles[:])
+	if isMethodNotFound(err) {
+		return o.legacyFees(ctx, speed)
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("get fee history: %w", err)
+	}
+	if len(history.BaseFeePerGas) == 0 || history.BaseFeePerGas[len(history.BaseFeePerGas)-1] == nil ||
+		history.BaseFeePerGas[len(history.BaseFeePerGas)-1].ToInt().Sign() == 0 {
+		// pre-London blocks have no base fee
+		return o.legacyFees(ctx, speed)
+	}
+
+	// the node appends the base fee of the next block, some leave it out and it is projected from the last one
+	baseFee := history.BaseFeePerGas[len(history.BaseFeePerGas)-1].ToInt()
+	if len(history.BaseFeePerGas) == len(history.GasUsedRatio) {
+		baseFee = ProjectBaseFee(baseFee, history.GasUsedRatio[len(history.GasUsedRatio)-1])
+	}
+	var tips []*big.Int
+	for i, rewards := range history.Reward {
+		// empty blocks report zero rewards
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		if int(speed) < len(rewards) {
+			tips = append(tips, rewards[speed].ToInt())
+		}
+	}
+	tip := medianBig(tips)
+
+	feeCap := new(big.Int).Mul(baseFee, big.NewInt(o.BaseFeeMultiplier))
+	feeCap.Add(feeCap, tip)
+	if o.MaxFeeCap != nil {
+		if baseFee.Cmp(o.MaxFeeCap) > 0 {
+			return nil, ErrFeeCapExceeded
+		}
+		if feeCap.Cmp(o.MaxFeeCap) > 0 {
+			feeCap = new(big.Int).Set(o.MaxFeeCap)
+		}
+		if headroom := new(big.Int).Sub(feeCap, baseFee); tip.Cmp(headroom) > 0 {
+			tip = headroom
+		}
+	}
+	return &FeeEstimate{BaseFee: baseFee, GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+func (o *FeeOracle) legacyFees(ctx context.Context, speed FeeSpeed) (*FeeEstimate, error) {
+	var price hexutil.Big
+	if err := o.client.CallContext(ctx, &price, "eth_gasPrice"); err != nil {
+		return nil, xerrors.Errorf("get gas price: %w", err)
+	}
+	gasPrice := new(big.Int).Mul(price.ToInt(), big.NewInt([]int64{90, 100, 125}[speed]))
+	gasPrice.Div(gasPrice, big.NewInt(100))
+	if o.MaxFeeCap != nil && gasPrice.Cmp(o.MaxFeeCap) > 0 {
+		if price.ToInt().Cmp(o.MaxFeeCap) > 0 {
+			return nil, ErrFeeCapExceeded
+		}
+		gasPrice = new(big.Int).Set(o.MaxFeeCap)
+	}
+	return &FeeEstimate{Legacy: true, GasFeeCap: gasPrice}, nil
+}
+
+// isMethodNotFound reports whether err is the error of a node not supporting the method.
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	return rpcErr.ErrorCode() == -32601 || strings.Contains(msg, "method not found") || strings.Contains(msg, "does not exist") ||
+		strings.Contains(msg, "not supported")
+}
+
+// ProjectBaseFee returns the base fee of the block after one with baseFee and gasUsedRatio, per EIP-1559.
+func ProjectBaseFee(baseFee *big.Int, gasUsedRatio float64) *big.Int {
+	// the base fee changes by at most 1/8 as the gas used deviates from the target of half the gas limit
+	const precision = 1000000
+	delta := new(big.Int).Mul(baseFee, big.NewInt(int64((gasUsedRatio-0.5)*2*precision)))
+	delta.Div(delta, big.NewInt(8*precision))
+	return new(big.Int).Add(baseFee, delta)
+}
+
+func medianBig(values []*big.Int) *big.Int {
+	if len(values) == 0 {
+		return new(big.Int)
+	}
+	sorted := append([]*big.Int{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	return new(big.Int).Set(sorted[len(sorted)/2])
+}
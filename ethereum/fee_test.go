@@ -0,0 +1,197 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFeeTestOracle returns an oracle of a node in mode london, projected (fee history without the next base fee),
+// legacy (without eth_feeHistory) or down.
+func newFeeTestOracle(t *testing.T, mode string) *FeeOracle {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if mode == "down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch {
+		case req.Method == "eth_feeHistory" && mode == "london":
+			resp["result"] = map[string]interface{}{
+				"oldestBlock":   "0x1",
+				"baseFeePerGas": []string{"0x3b9aca00", "0x3b9aca00", "0x3b9aca00", "0x4a817c800"},
+				"gasUsedRatio":  []float64{0.5, 0, 0.9},
+				"reward": [][]string{
+					{"0x1", "0x2", "0x3"},
+					{"0x0", "0x0", "0x0"},
+					{"0x5", "0x6", "0x7"},
+				},
+			}
+		case req.Method == "eth_feeHistory" && mode == "projected":
+			resp["result"] = map[string]interface{}{
+				"oldestBlock":   "0x1",
+				"baseFeePerGas": []string{"0x3b9aca00", "0x4a817c800"},
+				"gasUsedRatio":  []float64{0.5, 1},
+				"reward":        [][]string{{"0x1", "0x2", "0x3"}, {"0x5", "0x6", "0x7"}},
+			}
+		case req.Method == "eth_feeHistory":
+			resp["error"] = map[string]interface{}{"code": -32601, "message": "the method eth_feeHistory does not exist"}
+		case req.Method == "eth_gasPrice":
+			resp["result"] = "0x64"
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	_, client, err := NewClientWithRPC(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFeeOracle(client)
+}
+
+func TestFeeOracle(t *testing.T) {
+	oracle := newFeeTestOracle(t, "london")
+	fees, err := oracle.EstimateFees(context.Background(), FeeFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the empty block is skipped, the median of [3, 7] is 7
+	if fees.Legacy || fees.GasTipCap.Int64() != 7 || fees.BaseFee.Int64() != 20000000000 {
+		t.Errorf("wrong fees %+v", fees)
+	}
+	if fees.GasFeeCap.Int64() != 40000000007 {
+		t.Errorf("wrong fee cap %s", fees.GasFeeCap)
+	}
+
+	oracle.MaxFeeCap = big.NewInt(30000000000)
+	fees, err = oracle.EstimateFees(context.Background(), FeeFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasFeeCap.Cmp(oracle.MaxFeeCap) != 0 {
+		t.Errorf("fee cap %s not capped", fees.GasFeeCap)
+	}
+	oracle.MaxFeeCap = big.NewInt(1000)
+	if _, err := oracle.EstimateFees(context.Background(), FeeFast); err != ErrFeeCapExceeded {
+		t.Errorf("expected ErrFeeCapExceeded, got %v", err)
+	}
+}
+
+func TestFeeOracleLegacy(t *testing.T) {
+	fees, err := newFeeTestOracle(t, "legacy").EstimateFees(context.Background(), FeeFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fees.Legacy || fees.GasTipCap != nil || fees.GasFeeCap.Int64() != 125 {
+		t.Errorf("wrong legacy fees %+v", fees)
+	}
+
+	// a node that is down is not a pre-London node
+	if fees, err := newFeeTestOracle(t, "down").EstimateFees(context.Background(), FeeFast); err == nil {
+		t.Errorf("expected an error, got %+v", fees)
+	}
+}
+
+func TestFeeOracleProjectedBaseFee(t *testing.T) {
+	fees, err := newFeeTestOracle(t, "projected").EstimateFees(context.Background(), FeeFast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the last block is full, the next base fee is 20 gwei + 1/8
+	if fees.BaseFee.Int64() != 22500000000 || fees.GasFeeCap.Int64() != 45000000007 {
+		t.Errorf("wrong fees %+v", fees)
+	}
+}
+
+func TestAccount_TransactFeeCap(t *testing.T) {
+	var sent *types.Transaction
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_chainId":
+			rsp["result"] = "0x1"
+		case "eth_feeHistory":
+			rsp["result"] = map[string]interface{}{
+				"oldestBlock":   "0x1",
+				"baseFeePerGas": []string{"0x3b9aca00", "0x4a817c800"},
+				"gasUsedRatio":  []float64{0.5},
+				"reward":        [][]string{{"0x1", "0x2", "0x3"}},
+			}
+		case "eth_getBlockByNumber":
+			rsp["result"] = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), BaseFee: big.NewInt(20000000000)}
+		case "eth_maxPriorityFeePerGas":
+			rsp["result"] = "0x77359400"
+		case "eth_getTransactionCount":
+			rsp["result"] = "0x0"
+		case "eth_getCode":
+			rsp["result"] = "0x60"
+		case "eth_estimateGas":
+			rsp["result"] = "0xea60"
+		case "eth_sendRawTransaction":
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(req.Params[0], &raw)
+			sent = new(types.Transaction)
+			_ = sent.UnmarshalBinary(raw)
+			rsp["result"] = sent.Hash()
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	client, rpcClient, err := NewClientWithRPC(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.GenerateKey()
+	account := AccountFromPrivateKey(key)
+	oracle := NewFeeOracle(rpcClient)
+	oracle.MaxFeeCap = big.NewInt(30000000000)
+	account.FeeEstimator = oracle
+
+	// without the oracle the binding would pay 2 * 20 gwei + the 2 gwei tip of the node
+	tx, err := account.Transact(context.Background(), client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		contract := bind.NewBoundContract(common.Address{1}, abi.ABI{}, client, client, client)
+		return contract.RawTransact(opts, []byte{1, 2, 3, 4})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil || sent.Hash() != tx.Hash() {
+		t.Fatal("transaction not sent")
+	}
+	if tx.GasFeeCap().Cmp(oracle.MaxFeeCap) != 0 || tx.GasTipCap().Int64() != 2 {
+		t.Errorf("wrong fees: tip %s, fee cap %s", tx.GasTipCap(), tx.GasFeeCap())
+	}
+}
+
+func TestProjectBaseFee(t *testing.T) {
+	base := big.NewInt(800)
+	if v := ProjectBaseFee(base, 1); v.Int64() != 900 {
+		t.Errorf("full block: %s", v)
+	}
+	if v := ProjectBaseFee(base, 0.5); v.Int64() != 800 {
+		t.Errorf("target block: %s", v)
+	}
+	if v := ProjectBaseFee(base, 0); v.Int64() != 700 {
+		t.Errorf("empty block: %s", v)
+	}
+}
@@ -0,0 +1,314 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/xerrors"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// TransferEventID is the topic of the ERC-20 Transfer(address,address,uint256) event.
+var TransferEventID = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// ErrDeepReorg is returned when a reorg goes deeper than the block hashes kept in the checkpoint.
+var ErrDeepReorg = errors.New("reorg deeper than the checkpoint history")
+
+// ErrNonCanonicalLog is returned when a log of a scanned range is not in the canonical chain, because the chain
+// reorganized during the scan or the provider served a stale fork. Scanning again from the returned checkpoint
+// picks up the new chain.
+var ErrNonCanonicalLog = errors.New("log not in the canonical chain")
+
+// Deposit is an ERC-20 Transfer event to a watched recipient.
+type Deposit struct {
+	Token       common.Address
+	From        common.Address
+	To          common.Address
+	Amount      *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// Checkpoint is the resumable state of a TransferIndexer. It can be stored as JSON.
+type Checkpoint struct {
+	// BlockNumber is the last scanned block.
+	BlockNumber uint64 `json:"blockNumber"`
+	// BlockHashes holds the hashes of recently scanned blocks, used to find the fork point of a reorg.
+	BlockHashes map[uint64]common.Hash `json:"blockHashes"`
+}
+
+// NewCheckpoint returns a checkpoint that starts scanning after block.
+func NewCheckpoint(block uint64) *Checkpoint {
+	return &Checkpoint{BlockNumber: block, BlockHashes: map[uint64]common.Hash{}}
+}
+
+// IndexResult is the outcome of TransferIndexer.Scan.
+type IndexResult struct {
+	Deposits []*Deposit
+	// Reorged is set when previously scanned blocks after RevertTo were replaced.
+	// Deposits in those blocks that were emitted by earlier scans must be reverted, Deposits holds those of the new chain.
+	Reorged  bool
+	RevertTo uint64
+	// Checkpoint is the state to pass to the next Scan.
+	Checkpoint *Checkpoint
+}
+
+// TransferIndexer scans block ranges for ERC-20 Transfer events of many tokens to many recipients.
+type TransferIndexer struct {
+	client     *Client
+	tokens     []common.Address
+	recipients []common.Hash
+
+	// Confirmations is the number of blocks on top of a block before it is scanned.
+	Confirmations uint64
+	// MaxBlockRange caps the number of blocks per eth_getLogs call. The range is halved whenever
+	// the provider rejects a query for returning too many results, and grows back after successful queries.
+	MaxBlockRange uint64
+	// RecipientBatch is the number of recipient topics per eth_getLogs call.
+	RecipientBatch int
+	// HashHistory is the number of block hashes kept in the checkpoint for reorg detection.
+	HashHistory int
+
+	blockRange uint64
+}
+
+// NewTransferIndexer creates an indexer of transfers of tokens to recipients.
+// All transfers of the tokens are reported when recipients is empty.
+func NewTransferIndexer(client *Client, tokens []common.Address, recipients []common.Address) *TransferIndexer {
+	topics := make([]common.Hash, len(recipients))
+	for i, r := range recipients {
+		topics[i] = common.BytesToHash(r.Bytes())
+	}
+	return &TransferIndexer{
+		client:         client,
+		tokens:         tokens,
+		recipients:     topics,
+		Confirmations:  12,
+		MaxBlockRange:  2000,
+		RecipientBatch: 500,
+		HashHistory:    128,
+	}
+}
+
+// Scan indexes the confirmed blocks after the checkpoint and returns their deposits in chain order.
+// On error the returned result holds the deposits and checkpoint of the ranges scanned so far.
+func (s *TransferIndexer) Scan(ctx context.Context, checkpoint *Checkpoint) (*IndexResult, error) {
+	result := &IndexResult{Checkpoint: copyCheckpoint(checkpoint)}
+
+	forkPoint, err := s.findForkPoint(ctx, result.Checkpoint)
+	if err != nil {
+		return result, err
+	}
+	if forkPoint < result.Checkpoint.BlockNumber {
+		result.Reorged = true
+		result.RevertTo = forkPoint
+		result.Checkpoint.BlockNumber = forkPoint
+		for n := range result.Checkpoint.BlockHashes {
+			if n > forkPoint {
+				delete(result.Checkpoint.BlockHashes, n)
+			}
+		}
+	}
+
+	head, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return result, xerrors.Errorf("get block number: %w", err)
+	}
+	if head < s.Confirmations {
+		return result, nil
+	}
+	safe := head - s.Confirmations
+
+	if s.MaxBlockRange == 0 {
+		return result, xerrors.New("MaxBlockRange must be positive")
+	}
+	if s.blockRange == 0 || s.blockRange > s.MaxBlockRange {
+		s.blockRange = s.MaxBlockRange
+	}
+	for from := result.Checkpoint.BlockNumber + 1; from <= safe; {
+		to := from + s.blockRange - 1
+		if to > safe {
+			to = safe
+		}
+		// the header comes first, a reorg after it changes the hash of to and is found by the next findForkPoint
+		header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+		if err != nil {
+			return result, xerrors.Errorf("get header %d: %w", to, err)
+		}
+		deposits, err := s.scanRange(ctx, from, to)
+		if isTooManyResults(err) && s.blockRange > 1 {
+			s.blockRange /= 2
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if err := s.checkCanonical(ctx, deposits, header); err != nil {
+			return result, err
+		}
+
+		result.Deposits = append(result.Deposits, deposits...)
+		for _, d := range deposits {
+			result.Checkpoint.BlockHashes[d.BlockNumber] = d.BlockHash
+		}
+		result.Checkpoint.BlockHashes[to] = header.Hash()
+		result.Checkpoint.BlockNumber = to
+		s.pruneHashes(result.Checkpoint)
+
+		if s.blockRange < s.MaxBlockRange {
+			s.blockRange *= 2
+			if s.blockRange > s.MaxBlockRange {
+				s.blockRange = s.MaxBlockRange
+			}
+		}
+		from = to + 1
+	}
+	return result, nil
+}
+
+// findForkPoint returns the last block of the checkpoint still on the canonical chain,
+// the checkpoint block itself when there was no reorg.
+func (s *TransferIndexer) findForkPoint(ctx context.Context, checkpoint *Checkpoint) (uint64, error) {
+	if len(checkpoint.BlockHashes) == 0 {
+		return checkpoint.BlockNumber, nil
+	}
+	numbers := make([]uint64, 0, len(checkpoint.BlockHashes))
+	for n := range checkpoint.BlockHashes {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+
+	for i, n := range numbers {
+		header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return 0, xerrors.Errorf("get header %d: %w", n, err)
+		}
+		if header.Hash() == checkpoint.BlockHashes[n] {
+			if i == 0 {
+				return checkpoint.BlockNumber, nil
+			}
+			return n, nil
+		}
+	}
+	return 0, ErrDeepReorg
+}
+
+// checkCanonical checks that the deposits are in blocks of the canonical chain, whose block to is header.
+// Deposits from an orphaned fork would be kept under the hash of a canonical block and never reverted.
+func (s *TransferIndexer) checkCanonical(ctx context.Context, deposits []*Deposit, header *types.Header) error {
+	hashes := map[uint64]common.Hash{header.Number.Uint64(): header.Hash()}
+	for _, d := range deposits {
+		hash, ok := hashes[d.BlockNumber]
+		if !ok {
+			h, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(d.BlockNumber))
+			if err != nil {
+				return xerrors.Errorf("get header %d: %w", d.BlockNumber, err)
+			}
+			hash = h.Hash()
+			hashes[d.BlockNumber] = hash
+		}
+		if d.BlockHash != hash {
+			return xerrors.Errorf("transfer %s in block %d: %w", d.TxHash.Hex(), d.BlockNumber, ErrNonCanonicalLog)
+		}
+	}
+	return nil
+}
+
+func (s *TransferIndexer) pruneHashes(checkpoint *Checkpoint) {
+	if len(checkpoint.BlockHashes) <= s.HashHistory {
+		return
+	}
+	numbers := make([]uint64, 0, len(checkpoint.BlockHashes))
+	for n := range checkpoint.BlockHashes {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for _, n := range numbers[:len(numbers)-s.HashHistory] {
+		delete(checkpoint.BlockHashes, n)
+	}
+}
+
+func (s *TransferIndexer) scanRange(ctx context.Context, from uint64, to uint64) ([]*Deposit, error) {
+	batches := [][]common.Hash{nil}
+	if len(s.recipients) > 0 {
+		batches = nil
+		for i := 0; i < len(s.recipients); i += s.RecipientBatch {
+			end := i + s.RecipientBatch
+			if end > len(s.recipients) {
+				end = len(s.recipients)
+			}
+			batches = append(batches, s.recipients[i:end])
+		}
+	}
+
+	var deposits []*Deposit
+	for _, recipients := range batches {
+		logs, err := s.client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: s.tokens,
+			Topics:    [][]common.Hash{{TransferEventID}, nil, recipients},
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("get logs %d-%d: %w", from, to, err)
+		}
+		for i := range logs {
+			if d := ParseTransferLog(&logs[i]); d != nil {
+				deposits = append(deposits, d)
+			}
+		}
+	}
+	sort.Slice(deposits, func(i, j int) bool {
+		if deposits[i].BlockNumber != deposits[j].BlockNumber {
+			return deposits[i].BlockNumber < deposits[j].BlockNumber
+		}
+		return deposits[i].LogIndex < deposits[j].LogIndex
+	})
+	return deposits, nil
+}
+
+// ParseTransferLog decodes an ERC-20 Transfer log, it returns nil for other logs, including ERC-721 transfers.
+func ParseTransferLog(log *types.Log) *Deposit {
+	if log.Removed || len(log.Topics) != 3 || log.Topics[0] != TransferEventID || len(log.Data) != 32 {
+		return nil
+	}
+	return &Deposit{
+		Token:       log.Address,
+		From:        common.BytesToAddress(log.Topics[1].Bytes()),
+		To:          common.BytesToAddress(log.Topics[2].Bytes()),
+		Amount:      new(big.Int).SetBytes(log.Data),
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+	}
+}
+
+func copyCheckpoint(checkpoint *Checkpoint) *Checkpoint {
+	c := NewCheckpoint(checkpoint.BlockNumber)
+	for n, h := range checkpoint.BlockHashes {
+		c.BlockHashes[n] = h
+	}
+	return c
+}
+
+// isTooManyResults reports whether err is a provider rejecting an eth_getLogs query for its result size or range.
+func isTooManyResults(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"more than", "too many", "limit exceeded", "response size", "query timeout", "block range"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestParseTransferLog(t *testing.T) {
+	token := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	log := &types.Log{
+		Address:     token,
+		Topics:      []common.Hash{TransferEventID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(1000000).Bytes(), 32),
+		BlockNumber: 100,
+		Index:       3,
+	}
+
+	d := ParseTransferLog(log)
+	if d == nil {
+		t.Fatal("transfer log not parsed")
+	}
+	if d.Token != token || d.From != from || d.To != to || d.Amount.Int64() != 1000000 || d.LogIndex != 3 {
+		t.Errorf("wrong deposit %+v", d)
+	}
+
+	// ERC-721 transfers index the token id as a fourth topic
+	nft := *log
+	nft.Topics = append(append([]common.Hash{}, log.Topics...), common.Hash{})
+	nft.Data = nil
+	if ParseTransferLog(&nft) != nil {
+		t.Error("ERC-721 transfer parsed as ERC-20")
+	}
+
+	removed := *log
+	removed.Removed = true
+	if ParseTransferLog(&removed) != nil {
+		t.Error("removed log parsed")
+	}
+}
+
+func TestTransferIndexer_PruneHashes(t *testing.T) {
+	s := NewTransferIndexer(nil, nil, nil)
+	s.HashHistory = 2
+	c := NewCheckpoint(3)
+	for n := uint64(1); n <= 3; n++ {
+		c.BlockHashes[n] = common.BigToHash(new(big.Int).SetUint64(n))
+	}
+	s.pruneHashes(c)
+	if len(c.BlockHashes) != 2 {
+		t.Fatalf("expected 2 hashes, got %d", len(c.BlockHashes))
+	}
+	if _, ok := c.BlockHashes[1]; ok {
+		t.Error("oldest hash kept")
+	}
+}
+
+func TestIsTooManyResults(t *testing.T) {
+	if !isTooManyResults(errors.New("query returned more than 10000 results")) {
+		t.Error("expected result limit error")
+	}
+	if isTooManyResults(errors.New("connection refused")) {
+		t.Error("unexpected result limit error")
+	}
+}
+
+// indexerChain is a JSON-RPC node serving headers and Transfer logs of a chain that can be reorged.
+type indexerChain struct {
+	lock    sync.Mutex
+	headers []*types.Header
+	logs    map[uint64][]types.Log
+	// maxRange makes eth_getLogs fail for ranges of more blocks
+	maxRange uint64
+	ranges   [][2]uint64
+}
+
+// extend appends blocks up to head. Blocks from fork are replaced, fork 0 keeps the existing ones.
+func (c *indexerChain) extend(fork uint64, head uint64, tag byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if fork > 0 {
+		c.headers = c.headers[:fork]
+		for n := range c.logs {
+			if n >= fork {
+				delete(c.logs, n)
+			}
+		}
+	}
+	for n := uint64(len(c.headers)); n <= head; n++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(n), Difficulty: big.NewInt(1), Extra: []byte{tag}}
+		if n > 0 {
+			header.ParentHash = c.headers[n-1].Hash()
+		}
+		c.headers = append(c.headers, header)
+	}
+}
+
+func (c *indexerChain) deposit(block uint64, token common.Address, to common.Address, amount int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.logs[block] = append(c.logs[block], types.Log{
+		Address:     token,
+		Topics:      []common.Hash{TransferEventID, common.BytesToHash(common.Address{1}.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		BlockNumber: block,
+		BlockHash:   c.headers[block].Hash(),
+		TxHash:      common.BigToHash(big.NewInt(amount)),
+		Index:       uint(len(c.logs[block])),
+	})
+}
+
+func (c *indexerChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     interface{}       `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_blockNumber":
+		rsp["result"] = hexutil.Uint64(len(c.headers) - 1)
+	case "eth_getBlockByNumber":
+		var n hexutil.Uint64
+		_ = json.Unmarshal(req.Params[0], &n)
+		if int(n) < len(c.headers) {
+			rsp["result"] = c.headers[n]
+		} else {
+			rsp["result"] = nil
+		}
+	case "eth_getLogs":
+		var q struct {
+			FromBlock hexutil.Uint64 `json:"fromBlock"`
+			ToBlock   hexutil.Uint64 `json:"toBlock"`
+		}
+		_ = json.Unmarshal(req.Params[0], &q)
+		if uint64(q.ToBlock-q.FromBlock)+1 > c.maxRange {
+			rsp["error"] = map[string]interface{}{"code": -32005, "message": "query returned more than 10000 results"}
+			break
+		}
+		c.ranges = append(c.ranges, [2]uint64{uint64(q.FromBlock), uint64(q.ToBlock)})
+		logs := []types.Log{}
+		for n := uint64(q.FromBlock); n <= uint64(q.ToBlock); n++ {
+			logs = append(logs, c.logs[n]...)
+		}
+		rsp["result"] = logs
+	}
+	_ = json.NewEncoder(w).Encode(rsp)
+}
+
+func TestTransferIndexer_Scan(t *testing.T) {
+	token := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	deposit := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	chain := &indexerChain{logs: map[uint64][]types.Log{}, maxRange: 8}
+	chain.extend(0, 40, 'a')
+	chain.deposit(5, token, deposit, 5)
+	chain.deposit(20, token, deposit, 20)
+	chain.deposit(30, token, deposit, 30)
+	server := httptest.NewServer(chain)
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indexer := NewTransferIndexer(client, []common.Address{token}, []common.Address{deposit})
+	indexer.Confirmations = 2
+	indexer.MaxBlockRange = 16
+	result, err := indexer.Scan(context.Background(), NewCheckpoint(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reorged || result.Checkpoint.BlockNumber != 38 || len(result.Deposits) != 3 {
+		t.Fatalf("wrong result: reorged %v, block %d, %d deposits", result.Reorged, result.Checkpoint.BlockNumber, len(result.Deposits))
+	}
+	// the rejected 16 block queries are halved, every block is scanned once
+	next := uint64(1)
+	for _, r := range chain.ranges {
+		if r[0] != next || r[1]-r[0]+1 > chain.maxRange {
+			t.Fatalf("wrong ranges %v", chain.ranges)
+		}
+		next = r[1] + 1
+	}
+	if next != 39 {
+		t.Fatalf("wrong ranges %v", chain.ranges)
+	}
+
+	// resuming from the checkpoint without new blocks finds nothing
+	resumed, err := indexer.Scan(context.Background(), result.Checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Reorged || len(resumed.Deposits) != 0 || resumed.Checkpoint.BlockNumber != 38 {
+		t.Fatalf("wrong resumed result %+v", resumed)
+	}
+
+	// blocks from 27 are replaced, the deposit of block 30 moves to a new block hash
+	chain.extend(27, 45, 'b')
+	chain.deposit(30, token, deposit, 30)
+	chain.deposit(41, token, deposit, 41)
+	reorged, err := indexer.Scan(context.Background(), result.Checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reorged.Reorged || reorged.RevertTo != 24 || reorged.Checkpoint.BlockNumber != 43 {
+		t.Fatalf("wrong reorg: reorged %v, revert to %d, block %d", reorged.Reorged, reorged.RevertTo, reorged.Checkpoint.BlockNumber)
+	}
+	if len(reorged.Deposits) != 2 || reorged.Deposits[0].BlockNumber != 30 || reorged.Deposits[1].BlockNumber != 41 ||
+		reorged.Deposits[0].BlockHash != chain.headers[30].Hash() || reorged.Deposits[0].BlockHash == result.Deposits[2].BlockHash {
+		t.Fatalf("wrong deposits after reorg %+v", reorged.Deposits)
+	}
+	// the input checkpoint is left untouched
+	if result.Checkpoint.BlockNumber != 38 {
+		t.Error("checkpoint modified")
+	}
+
+	// the logs of block 44 come from a fork that is not canonical anymore
+	chain.extend(0, 50, 'b')
+	chain.deposit(44, token, deposit, 44)
+	chain.lock.Lock()
+	chain.logs[44][0].BlockHash = common.Hash{4}
+	chain.lock.Unlock()
+	stale, err := indexer.Scan(context.Background(), reorged.Checkpoint)
+	if !errors.Is(err, ErrNonCanonicalLog) {
+		t.Fatalf("expected ErrNonCanonicalLog, got %v", err)
+	}
+	if len(stale.Deposits) != 0 || stale.Checkpoint.BlockNumber != 43 {
+		t.Fatalf("stale deposits kept: block %d, %d deposits", stale.Checkpoint.BlockNumber, len(stale.Deposits))
+	}
+	chain.lock.Lock()
+	chain.logs[44][0].BlockHash = chain.headers[44].Hash()
+	chain.lock.Unlock()
+	fresh, err := indexer.Scan(context.Background(), stale.Checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh.Deposits) != 1 || fresh.Deposits[0].BlockHash != chain.headers[44].Hash() || fresh.Checkpoint.BlockNumber != 48 {
+		t.Fatalf("wrong deposits after rescan %+v", fresh.Deposits)
+	}
+
+	// none of the kept hashes is canonical anymore
+	deep := NewCheckpoint(43)
+	deep.BlockHashes[42] = common.Hash{1}
+	deep.BlockHashes[43] = common.Hash{2}
+	if _, err := indexer.Scan(context.Background(), deep); !errors.Is(err, ErrDeepReorg) {
+		t.Errorf("expected deep reorg, got %v", err)
+	}
+
+	indexer.MaxBlockRange = 0
+	if _, err := indexer.Scan(context.Background(), reorged.Checkpoint); err == nil {
+		t.Error("expected an error for a zero block range")
+	}
+}
@@ -0,0 +1,175 @@
+package ethereum
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/xerrors"
+	"io/ioutil"
+	"strings"
+)
+
+// KeystoreKDF is the key derivation function of a V3 keystore.
+type KeystoreKDF string
+
+const (
+	KeystoreScrypt KeystoreKDF = "scrypt"
+	KeystorePBKDF2 KeystoreKDF = "pbkdf2"
+)
+
+// pbkdf2Iterations is the iteration count used by geth and the Web3 Secret Storage test vectors.
+const pbkdf2Iterations = 262144
+
+type keystoreJSON struct {
+	Address string      `json:"address"`
+	Crypto  interface{} `json:"crypto"`
+	ID      string      `json:"id"`
+	Version int         `json:"version"`
+}
+
+// keystoreCryptoJSON mirrors keystore.CryptoJSON, whose cipher params type is not exported.
+type keystoreCryptoJSON struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams map[string]string      `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+// AccountFromHex creates an account from a hex encoded private key, with or without 0x prefix.
+func AccountFromHex(hexKey string) (*Account, error) {
+	pk, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimPrefix(hexKey, "0x"), "0X"))
+	if err != nil {
+		return nil, xerrors.Errorf("decode private key: %w", err)
+	}
+	return AccountFromPrivateKey(pk), nil
+}
+
+// Hex returns the hex encoded private key, without 0x prefix.
+func (account *Account) Hex() (string, error) {
+	if account.PrivateKey == nil {
+		return "", ErrNoPrivateKey
+	}
+	return hex.EncodeToString(crypto.FromECDSA(account.PrivateKey)), nil
+}
+
+// AccountFromKeystore decrypts a V3 keystore (Web3 Secret Storage) with scrypt or pbkdf2 key derivation.
+func AccountFromKeystore(keyJSON []byte, passphrase string) (*Account, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, xerrors.Errorf("decrypt keystore: %w", err)
+	}
+	var k keystoreJSON
+	if err := json.Unmarshal(keyJSON, &k); err != nil {
+		return nil, err
+	}
+	// the address is optional, but must match the key when present
+	if k.Address != "" && common.HexToAddress(k.Address) != key.Address {
+		return nil, xerrors.Errorf("keystore address %s does not match the key address %s", k.Address, key.Address.Hex())
+	}
+	return AccountFromPrivateKey(key.PrivateKey), nil
+}
+
+// AccountFromKeystoreFile decrypts a V3 keystore file.
+func AccountFromKeystoreFile(file string, passphrase string) (*Account, error) {
+	keyJSON, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return AccountFromKeystore(keyJSON, passphrase)
+}
+
+// EncryptKeystore encrypts the private key into a V3 keystore, with the standard parameters of the kdf.
+func (account *Account) EncryptKeystore(passphrase string, kdf KeystoreKDF) ([]byte, error) {
+	if account.PrivateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	keyBytes := math.PaddedBigBytes(account.PrivateKey.D, 32)
+	var cryptoJSON interface{}
+	var err error
+	switch kdf {
+	case KeystoreScrypt:
+		cryptoJSON, err = keystore.EncryptDataV3(keyBytes, []byte(passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	case KeystorePBKDF2:
+		cryptoJSON, err = encryptPBKDF2(keyBytes, []byte(passphrase))
+	default:
+		return nil, xerrors.Errorf("unsupported kdf %q", kdf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(keystoreJSON{
+		Address: hex.EncodeToString(account.Address[:]),
+		Crypto:  cryptoJSON,
+		ID:      id,
+		Version: 3,
+	})
+}
+
+// WriteKeystoreFile writes the account into a V3 keystore file readable only by the owner.
+func (account *Account) WriteKeystoreFile(file string, passphrase string, kdf KeystoreKDF) error {
+	keyJSON, err := account.EncryptKeystore(passphrase, kdf)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, keyJSON, 0600)
+}
+
+func encryptPBKDF2(data []byte, passphrase []byte) (*keystoreCryptoJSON, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	derivedKey := pbkdf2.Key(passphrase, salt, pbkdf2Iterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	cipherText := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, data)
+	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
+
+	return &keystoreCryptoJSON{
+		Cipher:       "aes-128-ctr",
+		CipherText:   hex.EncodeToString(cipherText),
+		CipherParams: map[string]string{"iv": hex.EncodeToString(iv)},
+		KDF:          string(KeystorePBKDF2),
+		KDFParams: map[string]interface{}{
+			"c":     pbkdf2Iterations,
+			"dklen": 32,
+			"prf":   "hmac-sha256",
+			"salt":  hex.EncodeToString(salt),
+		},
+		MAC: hex.EncodeToString(mac),
+	}, nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
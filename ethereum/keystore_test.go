@@ -0,0 +1,56 @@
+package ethereum
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Web3 Secret Storage Definition test vector
+const pbkdf2Keystore = `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+
+func TestAccountFromKeystore(t *testing.T) {
+	account, err := AccountFromKeystore([]byte(pbkdf2Keystore), "testpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := account.Hex(); err != nil || key != "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d" {
+		t.Errorf("wrong private key %s", key)
+	}
+	if _, err := AccountFromKeystore([]byte(pbkdf2Keystore), "wrong"); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	account, err := AccountFromHex("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kdf := range []KeystoreKDF{KeystorePBKDF2, KeystoreScrypt} {
+		file := filepath.Join(t.TempDir(), "key.json")
+		if err := account.WriteKeystoreFile(file, "secret", kdf); err != nil {
+			t.Fatal(err)
+		}
+		imported, err := AccountFromKeystoreFile(file, "secret")
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if imported.Address != account.Address || imported.PrivateKey.D.Cmp(account.PrivateKey.D) != 0 {
+			t.Errorf("%s: keystore gives a different account", kdf)
+		}
+	}
+}
+
+func TestExportWithoutPrivateKey(t *testing.T) {
+	account, err := AccountFromHex("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerOnly := AccountFromSigner(NewKeySigner(account.PrivateKey))
+	if _, err := signerOnly.Hex(); err != ErrNoPrivateKey {
+		t.Errorf("Hex: expected ErrNoPrivateKey, got %v", err)
+	}
+	if _, err := signerOnly.EncryptKeystore("secret", KeystorePBKDF2); err != ErrNoPrivateKey {
+		t.Errorf("EncryptKeystore: expected ErrNoPrivateKey, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package ethereum
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+)
+
+// Multicall3Address is the address of Multicall3, deployed at the same address on most EVM chains.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// NativeToken stands for the chain native currency (ETH) in balance requests.
+var NativeToken = common.Address{}
+
+const multicall3ABI = `[
+{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"},
+{"inputs":[{"name":"addr","type":"address"}],"name":"getEthBalance","outputs":[{"name":"balance","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+// MulticallCall is a single call of a batch.
+type MulticallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// MulticallResult is the outcome of a MulticallCall.
+type MulticallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicall batches read-only calls through the Multicall3 contract.
+type Multicall struct {
+	client  *Client
+	Address common.Address
+
+	// GasLimit is the gas given to each eth_call. Batches are sized to fit in it.
+	GasLimit uint64
+	// CallGas is the gas budgeted for each call of a batch.
+	CallGas uint64
+
+	abi abi.ABI
+}
+
+// NewMulticall creates a Multicall using the canonical Multicall3 deployment.
+func NewMulticall(client *Client) (*Multicall, error) {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &Multicall{
+		client:   client,
+		Address:  Multicall3Address,
+		GasLimit: 30000000,
+		CallGas:  50000,
+		abi:      parsed,
+	}, nil
+}
+
+// Aggregate runs calls at block, or at the head block when block is nil, and returns the results
+// with the block number they were read at. Calls are split into batches fitting GasLimit.
+// Calls with AllowFailure report failures in their result, other failures fail the whole batch.
+func (m *Multicall) Aggregate(ctx context.Context, calls []MulticallCall, block *big.Int) ([]MulticallResult, uint64, error) {
+	if block == nil {
+		head, err := m.client.BlockNumber(ctx)
+		if err != nil {
+			return nil, 0, xerrors.Errorf("get block number: %w", err)
+		}
+		block = new(big.Int).SetUint64(head)
+	}
+
+	size := len(calls)
+	if m.CallGas > 0 && m.GasLimit/m.CallGas < uint64(size) {
+		size = int(m.GasLimit / m.CallGas)
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	results := make([]MulticallResult, 0, len(calls))
+	for start := 0; start < len(calls); {
+		end := start + size
+		if end > len(calls) {
+			end = len(calls)
+		}
+		batch, err := m.aggregate(ctx, calls[start:end], block)
+		if err != nil && isOutOfGas(err) && size > 1 {
+			size /= 2
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		results = append(results, batch...)
+		start = end
+	}
+	return results, block.Uint64(), nil
+}
+
+func (m *Multicall) aggregate(ctx context.Context, calls []MulticallCall, block *big.Int) ([]MulticallResult, error) {
+	data, err := m.abi.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+	out, err := m.client.CallContract(ctx, ethereum.CallMsg{To: &m.Address, Gas: m.GasLimit, Data: data}, block)
+	if err != nil {
+		return nil, xerrors.Errorf("call aggregate3: %w", err)
+	}
+	unpacked, err := m.abi.Unpack("aggregate3", out)
+	if err != nil {
+		return nil, xerrors.Errorf("decode aggregate3: %w", err)
+	}
+	results := *abi.ConvertType(unpacked[0], new([]MulticallResult)).(*[]MulticallResult)
+	if len(results) != len(calls) {
+		return nil, xerrors.Errorf("aggregate3 returned %d results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
+
+// BalanceRequest asks the balance of Owner in Token, or in ETH when Token is NativeToken.
+type BalanceRequest struct {
+	Token common.Address
+	Owner common.Address
+}
+
+// BalanceResult is the balance of a BalanceRequest. Balance is nil when the call failed.
+type BalanceResult struct {
+	Token   common.Address
+	Owner   common.Address
+	Balance *big.Int
+	Success bool
+}
+
+// Balances returns the token balances of the requests at block, or at the head block when block is nil,
+// and the block number they were read at.
+func (m *Multicall) Balances(ctx context.Context, requests []BalanceRequest, block *big.Int) ([]*BalanceResult, uint64, error) {
+	erc20, err := ERC20MetaData.GetAbi()
+	if err != nil {
+		return nil, 0, err
+	}
+	calls := make([]MulticallCall, len(requests))
+	for i, r := range requests {
+		var data []byte
+		if r.Token == NativeToken {
+			data, err = m.abi.Pack("getEthBalance", r.Owner)
+			calls[i] = MulticallCall{Target: m.Address, AllowFailure: true, CallData: data}
+		} else {
+			data, err = erc20.Pack("balanceOf", r.Owner)
+			calls[i] = MulticallCall{Target: r.Token, AllowFailure: true, CallData: data}
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	out, number, err := m.Aggregate(ctx, calls, block)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]*BalanceResult, len(requests))
+	for i, r := range requests {
+		results[i] = &BalanceResult{Token: r.Token, Owner: r.Owner}
+		results[i].Balance, results[i].Success = decodeUint256(out[i])
+	}
+	return results, number, nil
+}
+
+// AllowanceRequest asks the amount of Token that Spender may transfer from Owner.
+type AllowanceRequest struct {
+	Token   common.Address
+	Owner   common.Address
+	Spender common.Address
+}
+
+// AllowanceResult is the allowance of an AllowanceRequest. Allowance is nil when the call failed.
+type AllowanceResult struct {
+	Token     common.Address
+	Owner     common.Address
+	Spender   common.Address
+	Allowance *big.Int
+	Success   bool
+}
+
+// Allowances returns the token allowances of the requests at block, or at the head block when block is nil,
+// and the block number they were read at.
+func (m *Multicall) Allowances(ctx context.Context, requests []AllowanceRequest, block *big.Int) ([]*AllowanceResult, uint64, error) {
+	erc20, err := ERC20MetaData.GetAbi()
+	if err != nil {
+		return nil, 0, err
+	}
+	calls := make([]MulticallCall, len(requests))
+	for i, r := range requests {
+		data, err := erc20.Pack("allowance", r.Owner, r.Spender)
+		if err != nil {
+			return nil, 0, err
+		}
+		calls[i] = MulticallCall{Target: r.Token, AllowFailure: true, CallData: data}
+	}
+
+	out, number, err := m.Aggregate(ctx, calls, block)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := make([]*AllowanceResult, len(requests))
+	for i, r := range requests {
+		results[i] = &AllowanceResult{Token: r.Token, Owner: r.Owner, Spender: r.Spender}
+		results[i].Allowance, results[i].Success = decodeUint256(out[i])
+	}
+	return results, number, nil
+}
+
+// decodeUint256 decodes a uint256 return value. Calls to addresses without code succeed with no data.
+func decodeUint256(result MulticallResult) (*big.Int, bool) {
+	if !result.Success || len(result.ReturnData) != 32 {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(result.ReturnData), true
+}
+
+func isOutOfGas(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "out of gas") || strings.Contains(msg, "gas required exceeds") || strings.Contains(msg, "gas limit")
+}
@@ -0,0 +1,118 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMulticallBalances(t *testing.T) {
+	usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	broken := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	parsed, _ := abi.JSON(strings.NewReader(multicall3ABI))
+	aggregate3 := parsed.Methods["aggregate3"]
+
+	var batches int
+	var blockTags []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		switch req.Method {
+		case "eth_blockNumber":
+			result = "0x64"
+		case "eth_call":
+			var msg struct {
+				Data  hexutil.Bytes `json:"data"`
+				Input hexutil.Bytes `json:"input"`
+			}
+			var tag string
+			_ = json.Unmarshal(req.Params[0], &msg)
+			_ = json.Unmarshal(req.Params[1], &tag)
+			data := msg.Data
+			if len(data) == 0 {
+				data = msg.Input
+			}
+			batches++
+			blockTags = append(blockTags, tag)
+
+			args, err := aggregate3.Inputs.Unpack(data[4:])
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			calls := *abi.ConvertType(args[0], new([]MulticallCall)).(*[]MulticallCall)
+			results := make([]MulticallResult, len(calls))
+			for i, call := range calls {
+				switch call.Target {
+				case Multicall3Address:
+					results[i] = MulticallResult{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(1e18).Bytes(), 32)}
+				case broken:
+					results[i] = MulticallResult{Success: false}
+				default:
+					results[i] = MulticallResult{Success: true, ReturnData: common.LeftPadBytes(big.NewInt(100).Bytes(), 32)}
+				}
+			}
+			out, err := aggregate3.Outputs.Pack(results)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			result = hexutil.Encode(out)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMulticall(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two calls per batch
+	m.GasLimit = 2 * m.CallGas
+
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	results, block, err := m.Balances(context.Background(), []BalanceRequest{
+		{Token: NativeToken, Owner: owner},
+		{Token: usdt, Owner: owner},
+		{Token: broken, Owner: owner},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != 100 {
+		t.Errorf("wrong block %d", block)
+	}
+	if batches != 2 {
+		t.Errorf("expected 2 batches, got %d", batches)
+	}
+	for _, tag := range blockTags {
+		if tag != "0x64" {
+			t.Errorf("batch not pinned to block 100: %s", tag)
+		}
+	}
+	if !results[0].Success || results[0].Balance.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("wrong ETH balance %+v", results[0])
+	}
+	if !results[1].Success || results[1].Balance.Int64() != 100 {
+		t.Errorf("wrong token balance %+v", results[1])
+	}
+	if results[2].Success || results[2].Balance != nil {
+		t.Errorf("failed call reported as success %+v", results[2])
+	}
+}
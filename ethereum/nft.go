@@ -0,0 +1,321 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+)
+
+// ERC-165 interface identifiers.
+var (
+	InterfaceIDERC165             = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+	InterfaceIDERC721             = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	InterfaceIDERC721Metadata     = [4]byte{0x5b, 0x5e, 0x13, 0x9f}
+	InterfaceIDERC1155            = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+	InterfaceIDERC1155MetadataURI = [4]byte{0x0e, 0x89, 0x34, 0x1c}
+)
+
+// NFTStandard is the token standard implemented by a contract.
+type NFTStandard string
+
+const (
+	NFTStandardUnknown NFTStandard = ""
+	NFTStandardERC721  NFTStandard = "ERC721"
+	NFTStandardERC1155 NFTStandard = "ERC1155"
+)
+
+// SupportsInterface reports whether the contract at address implements the interface, following the ERC-165
+// detection steps. Contracts without ERC-165 or reverting the query do not support it.
+func SupportsInterface(ctx context.Context, client *Client, address common.Address, interfaceID [4]byte) (bool, error) {
+	ok, err := callSupportsInterface(ctx, client, address, InterfaceIDERC165)
+	if err != nil || !ok {
+		return false, err
+	}
+	ok, err = callSupportsInterface(ctx, client, address, [4]byte{0xff, 0xff, 0xff, 0xff})
+	if err != nil || ok {
+		return false, err
+	}
+	return callSupportsInterface(ctx, client, address, interfaceID)
+}
+
+// DetectNFTStandard returns the NFT standard of the contract at address with ERC-165.
+func DetectNFTStandard(ctx context.Context, client *Client, address common.Address) (NFTStandard, error) {
+	for _, s := range []struct {
+		standard NFTStandard
+		id       [4]byte
+	}{{NFTStandardERC721, InterfaceIDERC721}, {NFTStandardERC1155, InterfaceIDERC1155}} {
+		ok, err := SupportsInterface(ctx, client, address, s.id)
+		if err != nil {
+			return NFTStandardUnknown, err
+		}
+		if ok {
+			return s.standard, nil
+		}
+	}
+	return NFTStandardUnknown, nil
+}
+
+func callSupportsInterface(ctx context.Context, client *Client, address common.Address, interfaceID [4]byte) (bool, error) {
+	// supportsInterface(bytes4), the ERC-165 query is limited to 30000 gas
+	data := append([]byte{0x01, 0xff, 0xc9, 0xa7}, common.RightPadBytes(interfaceID[:], 32)...)
+	out, err := client.CallContract(ctx, ethereum.CallMsg{To: &address, Gas: 30000, Data: data}, nil)
+	if isExecutionError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("call supportsInterface on %s: %w", address.Hex(), err)
+	}
+	return len(out) == 32 && new(big.Int).SetBytes(out).Cmp(common.Big1) == 0, nil
+}
+
+// isExecutionError reports whether err is the node reporting a call that failed in the EVM, reverted, out of gas
+// or on an invalid opcode, rather than a provider error such as a rate limit.
+func isExecutionError(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	// geth answers reverts with data with code 3
+	if rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, s := range []string{"revert", "out of gas", "invalid opcode", "invalid jump", "stack underflow", "vm execution error"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NFTCollection is an ERC-721 contract with its metadata, read once when it is created.
+type NFTCollection struct {
+	Address common.Address
+	Name    string
+	Symbol  string
+
+	client   *Client
+	contract *ERC721
+}
+
+// NewNFTCollection binds the ERC-721 contract at address after checking it with ERC-165, and reads its name and symbol.
+func NewNFTCollection(ctx context.Context, client *Client, address common.Address) (*NFTCollection, error) {
+	ok, err := SupportsInterface(ctx, client, address, InterfaceIDERC721)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("%s is not an ERC-721 contract", address.Hex())
+	}
+	contract, err := NewERC721(address, client)
+	if err != nil {
+		return nil, err
+	}
+	// the metadata extension is optional
+	opts := &bind.CallOpts{Context: ctx}
+	name, _ := contract.Name(opts)
+	symbol, _ := contract.Symbol(opts)
+	return &NFTCollection{
+		Address:  address,
+		Name:     name,
+		Symbol:   symbol,
+		client:   client,
+		contract: contract,
+	}, nil
+}
+
+// Contract returns the underlying binding.
+func (c *NFTCollection) Contract() *ERC721 {
+	return c.contract
+}
+
+// OwnerOf returns the owner of the token.
+func (c *NFTCollection) OwnerOf(ctx context.Context, tokenID *big.Int) (common.Address, error) {
+	return c.contract.OwnerOf(&bind.CallOpts{Context: ctx}, tokenID)
+}
+
+// BalanceOf returns the number of tokens owned by owner.
+func (c *NFTCollection) BalanceOf(ctx context.Context, owner common.Address) (*big.Int, error) {
+	return c.contract.BalanceOf(&bind.CallOpts{Context: ctx}, owner)
+}
+
+// TokenURI returns the metadata URI of the token.
+func (c *NFTCollection) TokenURI(ctx context.Context, tokenID *big.Int) (string, error) {
+	return c.contract.TokenURI(&bind.CallOpts{Context: ctx}, tokenID)
+}
+
+// IsApproved reports whether spender may transfer the token, as its owner, approved address or operator.
+func (c *NFTCollection) IsApproved(ctx context.Context, spender common.Address, tokenID *big.Int) (bool, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	owner, err := c.contract.OwnerOf(opts, tokenID)
+	if err != nil {
+		return false, xerrors.Errorf("get owner of %s: %w", tokenID, err)
+	}
+	if owner == spender {
+		return true, nil
+	}
+	approved, err := c.contract.GetApproved(opts, tokenID)
+	if err != nil {
+		return false, xerrors.Errorf("get approved of %s: %w", tokenID, err)
+	}
+	if approved == spender {
+		return true, nil
+	}
+	return c.contract.IsApprovedForAll(opts, owner, spender)
+}
+
+// MultiToken is an ERC-1155 contract.
+type MultiToken struct {
+	Address common.Address
+
+	client   *Client
+	contract *ERC1155
+}
+
+// NewMultiToken binds the ERC-1155 contract at address after checking it with ERC-165.
+func NewMultiToken(ctx context.Context, client *Client, address common.Address) (*MultiToken, error) {
+	ok, err := SupportsInterface(ctx, client, address, InterfaceIDERC1155)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("%s is not an ERC-1155 contract", address.Hex())
+	}
+	contract, err := NewERC1155(address, client)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiToken{Address: address, client: client, contract: contract}, nil
+}
+
+// Contract returns the underlying binding.
+func (t *MultiToken) Contract() *ERC1155 {
+	return t.contract
+}
+
+// BalanceOf returns the balance of owner for the token id.
+func (t *MultiToken) BalanceOf(ctx context.Context, owner common.Address, id *big.Int) (*big.Int, error) {
+	return t.contract.BalanceOf(&bind.CallOpts{Context: ctx}, owner, id)
+}
+
+// BalanceOfBatch returns the balance of owners[i] for ids[i] in a single call.
+func (t *MultiToken) BalanceOfBatch(ctx context.Context, owners []common.Address, ids []*big.Int) ([]*big.Int, error) {
+	if len(owners) != len(ids) {
+		return nil, errors.New("owners and ids length mismatch")
+	}
+	return t.contract.BalanceOfBatch(&bind.CallOpts{Context: ctx}, owners, ids)
+}
+
+// URI returns the metadata URI of the token id, with the {id} placeholder substituted as the standard requires.
+func (t *MultiToken) URI(ctx context.Context, id *big.Int) (string, error) {
+	uri, err := t.contract.Uri(&bind.CallOpts{Context: ctx}, id)
+	if err != nil {
+		return "", err
+	}
+	return expandTokenURI(uri, id), nil
+}
+
+// expandTokenURI replaces {id} with the lowercase hex id, zero-padded to 64 characters.
+func expandTokenURI(uri string, id *big.Int) string {
+	return strings.ReplaceAll(uri, "{id}", hex.EncodeToString(common.LeftPadBytes(id.Bytes(), 32)))
+}
+
+// TransferNFT transfers the token to the address to with safeTransferFrom, after checking the account owns it.
+func (account *Account) TransferNFT(ctx context.Context, collection *NFTCollection, to common.Address, tokenID *big.Int) (*types.Transaction, error) {
+	owner, err := collection.OwnerOf(ctx, tokenID)
+	if err != nil {
+		return nil, xerrors.Errorf("get owner of %s: %w", tokenID, err)
+	}
+	if owner != account.Address {
+		return nil, xerrors.Errorf("token %s is owned by %s", tokenID, owner.Hex())
+	}
+	return account.Transact(ctx, collection.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return collection.contract.SafeTransferFrom(opts, account.Address, to, tokenID)
+	})
+}
+
+// TransferNFTFrom transfers the token from the address from to the address to with safeTransferFrom,
+// after checking the account is approved for it.
+func (account *Account) TransferNFTFrom(ctx context.Context, collection *NFTCollection, from common.Address, to common.Address, tokenID *big.Int) (*types.Transaction, error) {
+	owner, err := collection.OwnerOf(ctx, tokenID)
+	if err != nil {
+		return nil, xerrors.Errorf("get owner of %s: %w", tokenID, err)
+	}
+	if owner != from {
+		return nil, xerrors.Errorf("token %s is owned by %s", tokenID, owner.Hex())
+	}
+	approved, err := collection.IsApproved(ctx, account.Address, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return nil, xerrors.Errorf("not approved for token %s", tokenID)
+	}
+	return account.Transact(ctx, collection.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return collection.contract.SafeTransferFrom(opts, from, to, tokenID)
+	})
+}
+
+// ApproveNFT approves the address to to transfer the token. The zero address clears the approval.
+func (account *Account) ApproveNFT(ctx context.Context, collection *NFTCollection, to common.Address, tokenID *big.Int) (*types.Transaction, error) {
+	return account.Transact(ctx, collection.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return collection.contract.Approve(opts, to, tokenID)
+	})
+}
+
+// SetNFTApprovalForAll allows or revokes operator to transfer all tokens of the account in the collection.
+func (account *Account) SetNFTApprovalForAll(ctx context.Context, collection *NFTCollection, operator common.Address, approved bool) (*types.Transaction, error) {
+	return account.Transact(ctx, collection.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return collection.contract.SetApprovalForAll(opts, operator, approved)
+	})
+}
+
+// TransferMultiToken transfers amount of the token id to the address to, after checking the balance.
+func (account *Account) TransferMultiToken(ctx context.Context, token *MultiToken, to common.Address, id *big.Int, amount *big.Int, data []byte) (*types.Transaction, error) {
+	return account.BatchTransferMultiToken(ctx, token, to, []*big.Int{id}, []*big.Int{amount}, data)
+}
+
+// BatchTransferMultiToken transfers amounts[i] of ids[i] to the address to, after checking the balances.
+// A single id is sent with safeTransferFrom, several with safeBatchTransferFrom.
+func (account *Account) BatchTransferMultiToken(ctx context.Context, token *MultiToken, to common.Address, ids []*big.Int, amounts []*big.Int, data []byte) (*types.Transaction, error) {
+	if len(ids) == 0 || len(ids) != len(amounts) {
+		return nil, errors.New("ids and amounts length mismatch")
+	}
+	owners := make([]common.Address, len(ids))
+	for i, amount := range amounts {
+		if amount.Sign() <= 0 {
+			return nil, errors.New("invalid value")
+		}
+		owners[i] = account.Address
+	}
+	balances, err := token.BalanceOfBatch(ctx, owners, ids)
+	if err != nil {
+		return nil, xerrors.Errorf("get balances: %w", err)
+	}
+	for i, balance := range balances {
+		if balance.Cmp(amounts[i]) < 0 {
+			return nil, xerrors.Errorf("not enough of token %s: %s < %s", ids[i], balance, amounts[i])
+		}
+	}
+	return account.Transact(ctx, token.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		if len(ids) == 1 {
+			return token.contract.SafeTransferFrom(opts, account.Address, to, ids[0], amounts[0], data)
+		}
+		return token.contract.SafeBatchTransferFrom(opts, account.Address, to, ids, amounts, data)
+	})
+}
+
+// SetMultiTokenApprovalForAll allows or revokes operator to transfer all tokens of the account in the contract.
+func (account *Account) SetMultiTokenApprovalForAll(ctx context.Context, token *MultiToken, operator common.Address, approved bool) (*types.Transaction, error) {
+	return account.Transact(ctx, token.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.contract.SetApprovalForAll(opts, operator, approved)
+	})
+}
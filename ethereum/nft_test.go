@@ -0,0 +1,119 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func interfaceID(t *testing.T, metaABI string, methods ...string) [4]byte {
+	parsed, err := abi.JSON(strings.NewReader(metaABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id [4]byte
+	for _, name := range methods {
+		method, ok := parsed.Methods[name]
+		if !ok {
+			t.Fatalf("missing method %s", name)
+		}
+		for i := range id {
+			id[i] ^= method.ID[i]
+		}
+	}
+	return id
+}
+
+func TestInterfaceIDs(t *testing.T) {
+	erc721 := interfaceID(t, ERC721MetaData.ABI, "balanceOf", "ownerOf", "safeTransferFrom", "safeTransferFrom0",
+		"transferFrom", "approve", "setApprovalForAll", "getApproved", "isApprovedForAll")
+	if erc721 != InterfaceIDERC721 {
+		t.Errorf("wrong ERC-721 interface id %x", erc721)
+	}
+	metadata := interfaceID(t, ERC721MetaData.ABI, "name", "symbol", "tokenURI")
+	if metadata != InterfaceIDERC721Metadata {
+		t.Errorf("wrong ERC-721 metadata interface id %x", metadata)
+	}
+	erc1155 := interfaceID(t, ERC1155MetaData.ABI, "safeTransferFrom", "safeBatchTransferFrom", "balanceOf",
+		"balanceOfBatch", "setApprovalForAll", "isApprovedForAll")
+	if erc1155 != InterfaceIDERC1155 {
+		t.Errorf("wrong ERC-1155 interface id %x", erc1155)
+	}
+}
+
+func TestExpandTokenURI(t *testing.T) {
+	uri := expandTokenURI("https://token-cdn-domain/{id}.json", big.NewInt(314592))
+	if uri != "https://token-cdn-domain/000000000000000000000000000000000000000000000000000000000004cce0.json" {
+		t.Errorf("wrong uri %s", uri)
+	}
+}
+
+func TestSupportsInterface(t *testing.T) {
+	nft := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	everything := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	reverting := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	limited := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	var queried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Params []struct {
+				To   common.Address `json:"to"`
+				Data hexutil.Bytes  `json:"data"`
+			} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		to, id := req.Params[0].To, hex.EncodeToString(req.Params[0].Data[4:8])
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch to {
+		case nft:
+			queried = append(queried, id)
+			result := make([]byte, 32)
+			if id == "01ffc9a7" || id == "80ac58cd" {
+				result[31] = 1
+			}
+			rsp["result"] = hexutil.Bytes(result)
+		case everything:
+			rsp["result"] = hexutil.Bytes(common.LeftPadBytes([]byte{1}, 32))
+		case reverting:
+			rsp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+		case limited:
+			rsp["error"] = map[string]interface{}{"code": -32005, "message": "rate limit exceeded"}
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if ok, err := SupportsInterface(ctx, client, nft, InterfaceIDERC721); err != nil || !ok {
+		t.Errorf("ERC-721 not detected: %v", err)
+	}
+	if strings.Join(queried, ",") != "01ffc9a7,ffffffff,80ac58cd" {
+		t.Errorf("wrong detection steps %v", queried)
+	}
+	if ok, err := SupportsInterface(ctx, client, nft, InterfaceIDERC1155); err != nil || ok {
+		t.Errorf("ERC-1155 detected: %v", err)
+	}
+	// answering true to 0xffffffff is not ERC-165
+	if ok, err := SupportsInterface(ctx, client, everything, InterfaceIDERC721); err != nil || ok {
+		t.Errorf("invalid ERC-165 contract detected: %v", err)
+	}
+	if standard, err := DetectNFTStandard(ctx, client, reverting); err != nil || standard != NFTStandardUnknown {
+		t.Errorf("reverting contract: %s %v", standard, err)
+	}
+	if _, err := DetectNFTStandard(ctx, client, limited); err == nil {
+		t.Error("expected the rate limit error")
+	}
+}
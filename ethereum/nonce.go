@@ -0,0 +1,427 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/xerrors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NonceState is the nonce state of an address, as persisted by a NonceStore.
+type NonceState struct {
+	// Next is the lowest nonce never handed out.
+	Next uint64 `json:"next"`
+	// InFlight maps handed out nonces to the hash of the transaction sent with them, zero until it is sent.
+	InFlight map[uint64]common.Hash `json:"inFlight"`
+	// Free holds handed out nonces that were released or dropped. They are handed out again first.
+	Free []uint64 `json:"free"`
+}
+
+// NonceStore persists nonce states across restarts.
+type NonceStore interface {
+	// Load returns the state of address, or nil if there is none.
+	Load(address common.Address) (*NonceState, error)
+	Save(address common.Address, state *NonceState) error
+}
+
+// MemoryNonceStore keeps nonce states in memory.
+type MemoryNonceStore struct {
+	lock   sync.Mutex
+	states map[common.Address][]byte
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{states: map[common.Address][]byte{}}
+}
+
+func (s *MemoryNonceStore) Load(address common.Address) (*NonceState, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.states[address]
+	if !ok {
+		return nil, nil
+	}
+	var state NonceState
+	return &state, json.Unmarshal(data, &state)
+}
+
+func (s *MemoryNonceStore) Save(address common.Address, state *NonceState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.states[address] = data
+	return nil
+}
+
+// FileNonceStore keeps the nonce state of each address in a JSON file of the directory Dir.
+type FileNonceStore struct {
+	Dir string
+}
+
+func NewFileNonceStore(dir string) (*FileNonceStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileNonceStore{Dir: dir}, nil
+}
+
+func (s *FileNonceStore) Load(address common.Address) (*NonceState, error) {
+	data, err := ioutil.ReadFile(s.file(address))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state NonceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, xerrors.Errorf("decode nonce state of %s: %w", address.Hex(), err)
+	}
+	return &state, nil
+}
+
+func (s *FileNonceStore) Save(address common.Address, state *NonceState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	// write and rename, so a crash never leaves a truncated file
+	tmp := s.file(address) + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file(address))
+}
+
+func (s *FileNonceStore) file(address common.Address) string {
+	return filepath.Join(s.Dir, strings.ToLower(address.Hex())+".json")
+}
+
+// NonceManager hands out sequential nonces to concurrent senders from the same addresses.
+// It is safe for concurrent use, operations on one address are serialized.
+type NonceManager struct {
+	client *Client
+	store  NonceStore
+
+	lock  sync.Mutex
+	locks map[common.Address]*sync.Mutex
+	// acquired holds the nonces handed out by this manager and not sent yet, to tell them from the ones left
+	// unsent by a previous run
+	acquired map[common.Address]map[uint64]bool
+}
+
+// NewNonceManager creates a nonce manager. A MemoryNonceStore is used when store is nil.
+func NewNonceManager(client *Client, store NonceStore) *NonceManager {
+	if store == nil {
+		store = NewMemoryNonceStore()
+	}
+	return &NonceManager{
+		client:   client,
+		store:    store,
+		locks:    map[common.Address]*sync.Mutex{},
+		acquired: map[common.Address]map[uint64]bool{},
+	}
+}
+
+func (m *NonceManager) addressLock(address common.Address) *sync.Mutex {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	l, ok := m.locks[address]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[address] = l
+	}
+	return l
+}
+
+func (m *NonceManager) setAcquired(address common.Address, nonce uint64, acquired bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !acquired {
+		delete(m.acquired[address], nonce)
+		return
+	}
+	if m.acquired[address] == nil {
+		m.acquired[address] = map[uint64]bool{}
+	}
+	m.acquired[address][nonce] = true
+}
+
+func (m *NonceManager) isAcquired(address common.Address, nonce uint64) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.acquired[address][nonce]
+}
+
+func (m *NonceManager) load(address common.Address) (*NonceState, error) {
+	state, err := m.store.Load(address)
+	if err != nil {
+		return nil, xerrors.Errorf("load nonce state of %s: %w", address.Hex(), err)
+	}
+	if state == nil {
+		state = &NonceState{}
+	}
+	if state.InFlight == nil {
+		state.InFlight = map[uint64]common.Hash{}
+	}
+	return state, nil
+}
+
+func (m *NonceManager) save(address common.Address, state *NonceState) error {
+	if err := m.store.Save(address, state); err != nil {
+		return xerrors.Errorf("save nonce state of %s: %w", address.Hex(), err)
+	}
+	return nil
+}
+
+// Acquire hands out the next nonce of address. It must be followed by Sent, Release or HandleSendError.
+// Transactions sent outside the manager are taken into account through the pending nonce of the node.
+func (m *NonceManager) Acquire(ctx context.Context, address common.Address) (uint64, error) {
+	l := m.addressLock(address)
+	l.Lock()
+	defer l.Unlock()
+
+	state, err := m.load(address)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := m.client.PendingNonceAt(ctx, address)
+	if err != nil {
+		return 0, xerrors.Errorf("get pending nonce: %w", err)
+	}
+	if pending > state.Next {
+		state.Next = pending
+	}
+	// nonces below the pending nonce have been used in the meantime
+	free := state.Free[:0]
+	for _, n := range state.Free {
+		if n >= pending {
+			free = append(free, n)
+		}
+	}
+	state.Free = free
+
+	var nonce uint64
+	if len(state.Free) > 0 {
+		sort.Slice(state.Free, func(i, j int) bool { return state.Free[i] < state.Free[j] })
+		nonce = state.Free[0]
+		state.Free = state.Free[1:]
+	} else {
+		nonce = state.Next
+		state.Next++
+	}
+	state.InFlight[nonce] = common.Hash{}
+	m.setAcquired(address, nonce, true)
+	return nonce, m.save(address, state)
+}
+
+// Sent records that the transaction hash was broadcast with nonce.
+func (m *NonceManager) Sent(address common.Address, nonce uint64, hash common.Hash) error {
+	l := m.addressLock(address)
+	l.Lock()
+	defer l.Unlock()
+
+	state, err := m.load(address)
+	if err != nil {
+		return err
+	}
+	state.InFlight[nonce] = hash
+	m.setAcquired(address, nonce, false)
+	return m.save(address, state)
+}
+
+// Release returns a nonce whose transaction was never broadcast, so it is handed out again.
+func (m *NonceManager) Release(address common.Address, nonce uint64) error {
+	l := m.addressLock(address)
+	l.Lock()
+	defer l.Unlock()
+
+	state, err := m.load(address)
+	if err != nil {
+		return err
+	}
+	release(state, nonce)
+	m.setAcquired(address, nonce, false)
+	return m.save(address, state)
+}
+
+func release(state *NonceState, nonce uint64) {
+	delete(state.InFlight, nonce)
+	state.Free = append(state.Free, nonce)
+	// give trailing nonces back to Next
+	sort.Slice(state.Free, func(i, j int) bool { return state.Free[i] < state.Free[j] })
+	for len(state.Free) > 0 && state.Free[len(state.Free)-1] == state.Next-1 {
+		state.Free = state.Free[:len(state.Free)-1]
+		state.Next--
+	}
+}
+
+// HandleSendError updates the state after broadcasting the transaction hash with nonce failed with sendErr.
+// It returns retry if the nonce was already used and the transaction must be signed again with a new nonce.
+// "already known" errors mean the transaction is in the pool and are not returned. The nonce is released only when
+// the node rejected the transaction; after any other error it stays in flight until Sync.
+func (m *NonceManager) HandleSendError(ctx context.Context, address common.Address, nonce uint64, hash common.Hash, sendErr error) (bool, error) {
+	msg := strings.ToLower(sendErr.Error())
+	switch {
+	case strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction"):
+		return false, m.Sent(address, nonce, hash)
+
+	case strings.Contains(msg, "nonce too low") || strings.Contains(msg, "replacement transaction underpriced"):
+		l := m.addressLock(address)
+		l.Lock()
+		defer l.Unlock()
+		state, err := m.load(address)
+		if err != nil {
+			return false, err
+		}
+		// the nonce is taken by another transaction, it is not handed out again
+		delete(state.InFlight, nonce)
+		m.setAcquired(address, nonce, false)
+		pending, err := m.client.PendingNonceAt(ctx, address)
+		if err != nil {
+			return false, xerrors.Errorf("get pending nonce: %w", err)
+		}
+		if pending > state.Next {
+			state.Next = pending
+		}
+		return true, m.save(address, state)
+	}
+
+	if isRejected(msg) {
+		if err := m.Release(address, nonce); err != nil {
+			return false, err
+		}
+		return false, sendErr
+	}
+	// timeouts and connection errors don't tell whether the node accepted the transaction, so the nonce stays in
+	// flight and Sync releases it if the transaction is not in the pool
+	if err := m.Sent(address, nonce, hash); err != nil {
+		return false, err
+	}
+	return false, sendErr
+}
+
+// rejectedErrors are the txpool errors of transactions the node did not accept.
+var rejectedErrors = []string{
+	"underpriced",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"gas limit reached",
+	"invalid sender",
+	"invalid transaction",
+	"oversized data",
+	"negative value",
+	"nonce too high",
+	"fee cap",
+	"tip higher than",
+	"tip above fee cap",
+	"less than block base fee",
+	"exceeds the configured cap",
+	"txpool is full",
+	"only replay-protected",
+}
+
+func isRejected(msg string) bool {
+	for _, s := range rejectedErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync forgets mined nonces and detects transactions dropped from the pool. Dropped nonces are returned and
+// handed out again by Acquire, as later transactions of the address are stuck until they are used.
+// Nonces acquired by a previous run and never marked Sent, e.g. after a crash, are dropped too when they are at or
+// above the pending nonce of the node, and forgotten below it. Run it at startup to close the gaps they leave.
+func (m *NonceManager) Sync(ctx context.Context, address common.Address) ([]uint64, error) {
+	l := m.addressLock(address)
+	l.Lock()
+	defer l.Unlock()
+
+	state, err := m.load(address)
+	if err != nil {
+		return nil, err
+	}
+	mined, err := m.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("get nonce: %w", err)
+	}
+	if mined > state.Next {
+		state.Next = mined
+	}
+	free := state.Free[:0]
+	for _, n := range state.Free {
+		if n >= mined {
+			free = append(free, n)
+		}
+	}
+	state.Free = free
+
+	var pending uint64
+	var pendingKnown bool
+	var dropped []uint64
+	for nonce, hash := range state.InFlight {
+		if nonce < mined {
+			delete(state.InFlight, nonce)
+			continue
+		}
+		if hash == (common.Hash{}) {
+			if m.isAcquired(address, nonce) {
+				// handed out and not sent yet
+				continue
+			}
+			if !pendingKnown {
+				if pending, err = m.client.PendingNonceAt(ctx, address); err != nil {
+					return nil, xerrors.Errorf("get pending nonce: %w", err)
+				}
+				pendingKnown = true
+			}
+			if nonce < pending {
+				// sent before the crash, the transaction is in the pool
+				delete(state.InFlight, nonce)
+			} else {
+				dropped = append(dropped, nonce)
+			}
+			continue
+		}
+		_, _, err := m.client.TransactionByHash(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			dropped = append(dropped, nonce)
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("get transaction %s: %w", hash.Hex(), err)
+		}
+	}
+	for _, nonce := range dropped {
+		release(state, nonce)
+	}
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i] < dropped[j] })
+	return dropped, m.save(address, state)
+}
+
+// InFlight returns the nonces handed out and not mined yet, with the hashes of their transactions.
+func (m *NonceManager) InFlight(address common.Address) (map[uint64]common.Hash, error) {
+	l := m.addressLock(address)
+	l.Lock()
+	defer l.Unlock()
+
+	state, err := m.load(address)
+	if err != nil {
+		return nil, err
+	}
+	return state.InFlight, nil
+}
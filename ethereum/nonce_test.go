@@ -0,0 +1,291 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newNonceTestClient(t *testing.T, pending *uint64, mined *uint64) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		switch req.Method {
+		case "eth_getTransactionCount":
+			if req.Params[1] == "pending" {
+				result = hexutil.Uint64(atomic.LoadUint64(pending))
+			} else {
+				result = hexutil.Uint64(atomic.LoadUint64(mined))
+			}
+		case "eth_getTransactionByHash":
+			// every transaction was dropped
+			result = nil
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+	pending, mined := uint64(5), uint64(5)
+	client := newNonceTestClient(t, &pending, &mined)
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	store, err := NewFileNonceStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewNonceManager(client, store)
+
+	// concurrent senders get distinct sequential nonces
+	var lock sync.Mutex
+	seen := map[uint64]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := m.Acquire(ctx, address)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			lock.Lock()
+			seen[nonce] = true
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	for n := uint64(5); n < 15; n++ {
+		if !seen[n] {
+			t.Fatalf("nonce %d not handed out", n)
+		}
+	}
+
+	// releasing the last nonce gives it back to Next, a gap is reused first
+	if err := m.Release(address, 14); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Release(address, 7); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.Acquire(ctx, address); n != 7 {
+		t.Errorf("expected released nonce 7, got %d", n)
+	}
+	if n, _ := m.Acquire(ctx, address); n != 14 {
+		t.Errorf("expected nonce 14, got %d", n)
+	}
+
+	// a transaction sent elsewhere with nonce 15 makes it too low
+	atomic.StoreUint64(&pending, 16)
+	retry, err := m.HandleSendError(ctx, address, 15, common.Hash{1}, errors.New("nonce too low"))
+	if err != nil || !retry {
+		t.Fatalf("expected retry, got %v %v", retry, err)
+	}
+	if n, _ := m.Acquire(ctx, address); n != 16 {
+		t.Errorf("expected nonce 16, got %d", n)
+	}
+
+	// the state survives a restart
+	m = NewNonceManager(client, store)
+	if err := m.Sent(address, 10, common.Hash{2}); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreUint64(&mined, 10)
+	dropped, err := m.Sync(ctx, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 16 was acquired before the restart and never sent
+	if len(dropped) != 2 || dropped[0] != 10 || dropped[1] != 16 {
+		t.Errorf("expected nonces 10 and 16 dropped, got %v", dropped)
+	}
+	inFlight, _ := m.InFlight(address)
+	for n := range inFlight {
+		if n < 10 {
+			t.Errorf("mined nonce %d still in flight", n)
+		}
+	}
+	atomic.StoreUint64(&pending, 10)
+	if n, _ := m.Acquire(ctx, address); n != 10 {
+		t.Errorf("expected dropped nonce 10, got %d", n)
+	}
+}
+
+func TestNonceManager_SyncUnsent(t *testing.T) {
+	ctx := context.Background()
+	pending, mined := uint64(5), uint64(5)
+	client := newNonceTestClient(t, &pending, &mined)
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	store, err := NewFileNonceStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewNonceManager(client, store)
+	for i := 0; i < 3; i++ {
+		if _, err := m.Acquire(ctx, address); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// crash after broadcasting nonce 5 and before marking any nonce sent
+	atomic.StoreUint64(&pending, 6)
+	m = NewNonceManager(client, store)
+	dropped, err := m.Sync(ctx, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 2 || dropped[0] != 6 || dropped[1] != 7 {
+		t.Errorf("expected unsent nonces 6 and 7 dropped, got %v", dropped)
+	}
+	if inFlight, _ := m.InFlight(address); len(inFlight) != 0 {
+		t.Errorf("unexpected nonces in flight %v", inFlight)
+	}
+	nonce, err := m.Acquire(ctx, address)
+	if err != nil || nonce != 6 {
+		t.Fatalf("expected nonce 6, got %d %v", nonce, err)
+	}
+
+	// a nonce acquired by the running manager is being sent and stays in flight
+	if dropped, err := m.Sync(ctx, address); err != nil || len(dropped) != 0 {
+		t.Errorf("expected nothing dropped, got %v %v", dropped, err)
+	}
+	if inFlight, _ := m.InFlight(address); len(inFlight) != 1 {
+		t.Errorf("expected nonce 6 in flight, got %v", inFlight)
+	}
+}
+
+func TestNonceManager_SendErrors(t *testing.T) {
+	ctx := context.Background()
+	pending, mined := uint64(5), uint64(5)
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	m := NewNonceManager(newNonceTestClient(t, &pending, &mined), NewMemoryNonceStore())
+
+	// the node may have accepted a transaction before timing out, its nonce is not handed out again
+	nonce, _ := m.Acquire(ctx, address)
+	if retry, err := m.HandleSendError(ctx, address, nonce, common.Hash{1}, errors.New("context deadline exceeded")); retry || err == nil {
+		t.Fatalf("expected the send error, got %v %v", retry, err)
+	}
+	if inFlight, _ := m.InFlight(address); inFlight[nonce] != (common.Hash{1}) {
+		t.Errorf("nonce %d not in flight: %v", nonce, inFlight)
+	}
+	// a rejected transaction gives the nonce back
+	nonce, _ = m.Acquire(ctx, address)
+	if nonce != 6 {
+		t.Fatalf("expected nonce 6, got %d", nonce)
+	}
+	if retry, err := m.HandleSendError(ctx, address, nonce, common.Hash{2}, errors.New("insufficient funds for gas * price + value")); retry || err == nil {
+		t.Fatalf("expected the send error, got %v %v", retry, err)
+	}
+	if n, _ := m.Acquire(ctx, address); n != 6 {
+		t.Errorf("expected released nonce 6, got %d", n)
+	}
+}
+
+func TestAccount_Transact(t *testing.T) {
+	var lock sync.Mutex
+	pending := uint64(5)
+	var sendErrors []string
+	var sent []*types.Transaction
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		lock.Lock()
+		defer lock.Unlock()
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_chainId":
+			rsp["result"] = "0x1"
+		case "eth_getTransactionCount":
+			rsp["result"] = hexutil.Uint64(pending)
+		case "eth_getBlockByNumber":
+			rsp["result"] = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+		case "eth_gasPrice":
+			rsp["result"] = "0x3b9aca00"
+		case "eth_getCode":
+			rsp["result"] = "0x60"
+		case "eth_estimateGas":
+			rsp["result"] = "0xea60"
+		case "eth_sendRawTransaction":
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(req.Params[0], &raw)
+			tx := new(types.Transaction)
+			_ = tx.UnmarshalBinary(raw)
+			if len(sendErrors) > 0 {
+				if sendErrors[0] == "nonce too low" {
+					pending = 8
+				}
+				rsp["error"] = map[string]interface{}{"code": -32000, "message": sendErrors[0]}
+				sendErrors = sendErrors[1:]
+				break
+			}
+			sent = append(sent, tx)
+			rsp["result"] = tx.Hash()
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	account := AccountFromPrivateKey(key)
+	account.NonceManager = NewNonceManager(client, NewMemoryNonceStore())
+	call := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		contract := bind.NewBoundContract(common.Address{1}, abi.ABI{}, client, client, client)
+		return contract.RawTransact(opts, []byte{1, 2, 3, 4})
+	}
+
+	// nonce 5 is in flight, 6 is used elsewhere and the call is made again with 8
+	if _, err := account.NonceManager.Acquire(ctx, account.Address); err != nil {
+		t.Fatal(err)
+	}
+	sendErrors = []string{"nonce too low"}
+	tx, err := account.Transact(ctx, client, call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce() != 8 || len(sent) != 1 || sent[0].Hash() != tx.Hash() {
+		t.Fatalf("expected nonce 8, got %d", tx.Nonce())
+	}
+	if inFlight, _ := account.NonceManager.InFlight(account.Address); inFlight[8] != tx.Hash() {
+		t.Errorf("nonce 8 not in flight: %v", inFlight)
+	}
+
+	// a rejected call gives its nonce back
+	sendErrors = []string{"insufficient funds for gas * price + value"}
+	if _, err := account.Transact(ctx, client, call); err == nil {
+		t.Fatal("expected insufficient funds")
+	}
+	if tx, err = account.Transact(ctx, client, call); err != nil || tx.Nonce() != 9 {
+		t.Fatalf("expected nonce 9, got %v %v", tx, err)
+	}
+}
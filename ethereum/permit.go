@@ -0,0 +1,336 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+)
+
+// PermitType is the permit variant implemented by a token.
+type PermitType int
+
+const (
+	// PermitUnsupported tokens need an Approve transaction.
+	PermitUnsupported PermitType = iota
+	// PermitEIP2612 is permit(owner, spender, value, deadline, v, r, s).
+	PermitEIP2612
+	// PermitDAI is permit(holder, spender, nonce, expiry, allowed, v, r, s), used by DAI and its forks.
+	PermitDAI
+)
+
+// ErrPermitUnsupported is returned when a token implements no known permit variant.
+var ErrPermitUnsupported = errors.New("token does not support permit")
+
+var (
+	permitEIP2612TypeHash = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	permitDAITypeHash     = crypto.Keccak256Hash([]byte("Permit(address holder,address spender,uint256 nonce,uint256 expiry,bool allowed)"))
+)
+
+const permitABI = `[
+{"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"name":"permit","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"holder","type":"address"},{"name":"spender","type":"address"},{"name":"nonce","type":"uint256"},{"name":"expiry","type":"uint256"},{"name":"allowed","type":"bool"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"name":"permitDAI","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[{"name":"owner","type":"address"}],"name":"nonces","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"DOMAIN_SEPARATOR","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"PERMIT_TYPEHASH","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"version","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"eip712Domain","outputs":[{"name":"fields","type":"bytes1"},{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"},{"name":"salt","type":"bytes32"},{"name":"extensions","type":"uint256[]"}],"stateMutability":"view","type":"function"}
+]`
+
+// Permit is a signed permit message, ready to be submitted by anyone.
+type Permit struct {
+	Type    PermitType
+	Token   common.Address
+	Owner   common.Address
+	Spender common.Address
+	// Value is the allowance granted by an EIP-2612 permit.
+	Value *big.Int
+	// Allowed grants (unlimited) or revokes the allowance with a DAI-style permit.
+	Allowed  bool
+	Nonce    *big.Int
+	Deadline *big.Int
+
+	V uint8
+	R [32]byte
+	S [32]byte
+}
+
+// Signature returns the 65-byte [R || S || V] signature.
+func (p *Permit) Signature() []byte {
+	sig := make([]byte, 0, crypto.SignatureLength)
+	sig = append(sig, p.R[:]...)
+	sig = append(sig, p.S[:]...)
+	return append(sig, p.V)
+}
+
+// Calldata returns the input of the permit call to the token contract.
+func (p *Permit) Calldata() ([]byte, error) {
+	parsed, err := abi.JSON(strings.NewReader(permitABI))
+	if err != nil {
+		return nil, err
+	}
+	switch p.Type {
+	case PermitEIP2612:
+		return parsed.Pack("permit", p.Owner, p.Spender, p.Value, p.Deadline, p.V, p.R, p.S)
+	case PermitDAI:
+		data, err := parsed.Pack("permitDAI", p.Owner, p.Spender, p.Nonce, p.Deadline, p.Allowed, p.V, p.R, p.S)
+		if err != nil {
+			return nil, err
+		}
+		// the contract function is named permit as well
+		copy(data, crypto.Keccak256([]byte("permit(address,address,uint256,uint256,bool,uint8,bytes32,bytes32)"))[:4])
+		return data, nil
+	}
+	return nil, ErrPermitUnsupported
+}
+
+// TypedData returns the EIP-712 document signed for the permit within the token domain.
+func (p *Permit) TypedData(domain TypedDataDomain) *TypedData {
+	typedData := &TypedData{core.TypedData{
+		Types: core.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      domain,
+	}}
+	if p.Type == PermitDAI {
+		typedData.Types["Permit"] = []TypedDataField{
+			{Name: "holder", Type: "address"},
+			{Name: "spender", Type: "address"},
+			{Name: "nonce", Type: "uint256"},
+			{Name: "expiry", Type: "uint256"},
+			{Name: "allowed", Type: "bool"},
+		}
+		typedData.Message = map[string]interface{}{
+			"holder":  p.Owner.Hex(),
+			"spender": p.Spender.Hex(),
+			"nonce":   (*math.HexOrDecimal256)(p.Nonce),
+			"expiry":  (*math.HexOrDecimal256)(p.Deadline),
+			"allowed": p.Allowed,
+		}
+		return typedData
+	}
+	typedData.Types["Permit"] = []TypedDataField{
+		{Name: "owner", Type: "address"},
+		{Name: "spender", Type: "address"},
+		{Name: "value", Type: "uint256"},
+		{Name: "nonce", Type: "uint256"},
+		{Name: "deadline", Type: "uint256"},
+	}
+	typedData.Message = map[string]interface{}{
+		"owner":    p.Owner.Hex(),
+		"spender":  p.Spender.Hex(),
+		"value":    (*math.HexOrDecimal256)(p.Value),
+		"nonce":    (*math.HexOrDecimal256)(p.Nonce),
+		"deadline": (*math.HexOrDecimal256)(p.Deadline),
+	}
+	return typedData
+}
+
+func (t *Token) permitContract() (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(permitABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(t.Address, parsed, t.client, t.client, t.client), nil
+}
+
+// PermitNonce returns the current permit nonce of owner.
+func (t *Token) PermitNonce(ctx context.Context, owner common.Address) (*big.Int, error) {
+	contract, err := t.permitContract()
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "nonces", owner); err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// DomainSeparator returns the EIP-712 domain separator of the token.
+func (t *Token) DomainSeparator(ctx context.Context) (common.Hash, error) {
+	contract, err := t.permitContract()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "DOMAIN_SEPARATOR"); err != nil {
+		return common.Hash{}, err
+	}
+	return *abi.ConvertType(out[0], new([32]byte)).(*[32]byte), nil
+}
+
+// DetectPermit returns the permit variant of the token. PERMIT_TYPEHASH tells the variants apart when the token
+// exposes it. Otherwise a token with DOMAIN_SEPARATOR and nonces is assumed to implement EIP-2612.
+// Only missing or reverting functions mean unsupported, provider errors are returned.
+func (t *Token) DetectPermit(ctx context.Context) (PermitType, error) {
+	if _, ok, err := t.callPermit(ctx, "DOMAIN_SEPARATOR"); err != nil || !ok {
+		return PermitUnsupported, err
+	}
+	if _, ok, err := t.callPermit(ctx, "nonces", common.Address{}); err != nil || !ok {
+		return PermitUnsupported, err
+	}
+	out, ok, err := t.callPermit(ctx, "PERMIT_TYPEHASH")
+	if err != nil {
+		return PermitUnsupported, err
+	}
+	if !ok {
+		return PermitEIP2612, nil
+	}
+	switch common.Hash(*abi.ConvertType(out[0], new([32]byte)).(*[32]byte)) {
+	case permitDAITypeHash:
+		return PermitDAI, nil
+	case permitEIP2612TypeHash:
+		return PermitEIP2612, nil
+	}
+	return PermitUnsupported, nil
+}
+
+// callPermit calls a view function of the permit ABI. ok is false when the token does not implement it,
+// the call reverts or returns no or malformed data.
+func (t *Token) callPermit(ctx context.Context, method string, args ...interface{}) ([]interface{}, bool, error) {
+	parsed, err := abi.JSON(strings.NewReader(permitABI))
+	if err != nil {
+		return nil, false, err
+	}
+	input, err := parsed.Pack(method, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	output, err := t.client.CallContract(ctx, ethereum.CallMsg{To: &t.Address, Data: input}, nil)
+	if isExecutionError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, xerrors.Errorf("call %s on %s: %w", method, t.Address.Hex(), err)
+	}
+	out, err := parsed.Unpack(method, output)
+	if err != nil || len(out) == 0 {
+		return nil, false, nil
+	}
+	return out, true, nil
+}
+
+// permitDomain returns the EIP-712 domain of the token, checked against its DOMAIN_SEPARATOR.
+// The version is read with EIP-5267 eip712Domain or version, and guessed if neither exists.
+func (t *Token) permitDomain(ctx context.Context) (TypedDataDomain, error) {
+	separator, err := t.DomainSeparator(ctx)
+	if err != nil {
+		return TypedDataDomain{}, xerrors.Errorf("get domain separator: %w", err)
+	}
+	chainID, err := t.client.ChainID(ctx)
+	if err != nil {
+		return TypedDataDomain{}, xerrors.Errorf("get chain id: %w", err)
+	}
+
+	names := []string{t.Name}
+	versions := []string{"1", "2"}
+	out, ok, err := t.callPermit(ctx, "eip712Domain")
+	if err != nil {
+		return TypedDataDomain{}, err
+	}
+	if ok {
+		names = append([]string{*abi.ConvertType(out[1], new(string)).(*string)}, names...)
+		versions = append([]string{*abi.ConvertType(out[2], new(string)).(*string)}, versions...)
+	} else {
+		out, ok, err := t.callPermit(ctx, "version")
+		if err != nil {
+			return TypedDataDomain{}, err
+		}
+		if ok {
+			versions = append([]string{*abi.ConvertType(out[0], new(string)).(*string)}, versions...)
+		}
+	}
+
+	for _, name := range names {
+		for _, version := range versions {
+			domain := TypedDataDomain{
+				Name:              name,
+				Version:           version,
+				ChainId:           (*math.HexOrDecimal256)(chainID),
+				VerifyingContract: t.Address.Hex(),
+			}
+			hash, err := (&Permit{}).TypedData(domain).DomainSeparator()
+			if err != nil {
+				return TypedDataDomain{}, err
+			}
+			if hash == separator {
+				return domain, nil
+			}
+		}
+	}
+	return TypedDataDomain{}, xerrors.Errorf("unknown EIP-712 domain of %s", t.Address.Hex())
+}
+
+// SignPermit signs a permit letting spender transfer value base units of the account tokens until deadline
+// (unix seconds). For DAI-style tokens a positive value grants an unlimited allowance and zero revokes it.
+// The permit can be submitted by the spender or a relayer, so the account pays no gas.
+func (account *Account) SignPermit(ctx context.Context, token *Token, spender common.Address, value *big.Int, deadline *big.Int) (*Permit, error) {
+	permitType, err := token.DetectPermit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if permitType == PermitUnsupported {
+		return nil, ErrPermitUnsupported
+	}
+	domain, err := token.permitDomain(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := token.PermitNonce(ctx, account.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("get permit nonce: %w", err)
+	}
+
+	permit := &Permit{
+		Type:     permitType,
+		Token:    token.Address,
+		Owner:    account.Address,
+		Spender:  spender,
+		Value:    value,
+		Allowed:  value.Sign() > 0,
+		Nonce:    nonce,
+		Deadline: deadline,
+	}
+	if permitType == PermitDAI {
+		permit.Value = new(big.Int)
+		if permit.Allowed {
+			permit.Value = math.MaxBig256
+		}
+	}
+	sig, err := account.SignTypedDataContext(ctx, permit.TypedData(domain))
+	if err != nil {
+		return nil, err
+	}
+	copy(permit.R[:], sig[:32])
+	copy(permit.S[:], sig[32:64])
+	permit.V = sig[64]
+	return permit, nil
+}
+
+// SubmitPermit sends the permit to the token contract, paying the gas from the account.
+func (account *Account) SubmitPermit(ctx context.Context, client *Client, permit *Permit) (*types.Transaction, error) {
+	data, err := permit.Calldata()
+	if err != nil {
+		return nil, err
+	}
+	contract := bind.NewBoundContract(permit.Token, abi.ABI{}, client, client, client)
+	return account.Transact(ctx, client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.RawTransact(opts, data)
+	})
+}
@@ -0,0 +1,210 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPermitCalldata(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	account := &Account{PrivateKey: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	domain := TypedDataDomain{
+		Name:              "USD Coin",
+		Version:           "2",
+		ChainId:           math.NewHexOrDecimal256(1),
+		VerifyingContract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	}
+
+	for _, c := range []struct {
+		permitType PermitType
+		selector   string
+	}{{PermitEIP2612, "0xd505accf"}, {PermitDAI, "0x8fcbaf0c"}} {
+		permit := &Permit{
+			Type:     c.permitType,
+			Token:    common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+			Owner:    account.Address,
+			Spender:  common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			Value:    big.NewInt(1000000),
+			Allowed:  true,
+			Nonce:    big.NewInt(0),
+			Deadline: big.NewInt(1700000000),
+		}
+		typedData := permit.TypedData(domain)
+		sig, err := account.SignTypedData(typedData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copy(permit.R[:], sig[:32])
+		copy(permit.S[:], sig[32:64])
+		permit.V = sig[64]
+
+		if !bytes.Equal(permit.Signature(), sig) {
+			t.Error("signature does not round trip")
+		}
+		signer, err := RecoverTypedDataAddress(typedData, permit.Signature())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signer != account.Address {
+			t.Errorf("recovered %s, want %s", signer.Hex(), account.Address.Hex())
+		}
+
+		data, err := permit.Calldata()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hexutil.Encode(data[:4]) != c.selector {
+			t.Errorf("wrong selector %s, want %s", hexutil.Encode(data[:4]), c.selector)
+		}
+	}
+
+	if _, err := (&Permit{}).Calldata(); err != ErrPermitUnsupported {
+		t.Errorf("expected ErrPermitUnsupported, got %v", err)
+	}
+}
+
+func TestPermitDomainVectors(t *testing.T) {
+	// DOMAIN_SEPARATOR of USDC and DAI on mainnet
+	for _, c := range []struct {
+		domain    TypedDataDomain
+		separator string
+	}{
+		{TypedDataDomain{Name: "USD Coin", Version: "2", ChainId: math.NewHexOrDecimal256(1), VerifyingContract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"},
+			"0x06c37168a7db5138defc7866392bb87a741f9b3d104deb5094588ce041cae335"},
+		{TypedDataDomain{Name: "Dai Stablecoin", Version: "1", ChainId: math.NewHexOrDecimal256(1), VerifyingContract: "0x6B175474E89094C44Da98b954EedeAC495271d0F"},
+			"0xdbb8cf42e1ecb028be3f3dbc922e1d878b963f411dc388ced501601c60f7c6f7"},
+	} {
+		separator, err := (&Permit{}).TypedData(c.domain).DomainSeparator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if separator.Hex() != c.separator {
+			t.Errorf("%s: got %s, want %s", c.domain.Name, separator.Hex(), c.separator)
+		}
+	}
+
+	// the digest is keccak256(0x1901 || DOMAIN_SEPARATOR || keccak256(PERMIT_TYPEHASH || owner || spender || value || nonce || deadline))
+	permit := &Permit{
+		Type:     PermitEIP2612,
+		Owner:    common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Spender:  common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		Value:    big.NewInt(1000000),
+		Nonce:    big.NewInt(3),
+		Deadline: big.NewInt(1700000000),
+	}
+	structHash := crypto.Keccak256(
+		permitEIP2612TypeHash.Bytes(),
+		common.LeftPadBytes(permit.Owner.Bytes(), 32),
+		common.LeftPadBytes(permit.Spender.Bytes(), 32),
+		common.LeftPadBytes(permit.Value.Bytes(), 32),
+		common.LeftPadBytes(permit.Nonce.Bytes(), 32),
+		common.LeftPadBytes(permit.Deadline.Bytes(), 32),
+	)
+	expected := crypto.Keccak256Hash([]byte{0x19, 0x01}, common.FromHex("0x06c37168a7db5138defc7866392bb87a741f9b3d104deb5094588ce041cae335"), structHash)
+	digest, err := permit.TypedData(TypedDataDomain{
+		Name: "USD Coin", Version: "2", ChainId: math.NewHexOrDecimal256(1), VerifyingContract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	}).Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != expected {
+		t.Errorf("wrong digest %s, want %s", digest.Hex(), expected.Hex())
+	}
+}
+
+func TestDetectPermit(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	parsed, err := abi.JSON(strings.NewReader(permitABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, _ := parsed.Methods["version"].Outputs.Pack("2")
+	results := map[string][]byte{
+		"DOMAIN_SEPARATOR": common.FromHex("0x06c37168a7db5138defc7866392bb87a741f9b3d104deb5094588ce041cae335"),
+		"nonces":           common.LeftPadBytes([]byte{3}, 32),
+		"PERMIT_TYPEHASH":  permitEIP2612TypeHash.Bytes(),
+		"version":          version,
+	}
+	mode := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+			Params []struct {
+				Data hexutil.Bytes `json:"data"`
+			} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if req.Method == "eth_chainId" {
+			rsp["result"] = "0x1"
+			_ = json.NewEncoder(w).Encode(rsp)
+			return
+		}
+		method, _ := parsed.MethodById(req.Params[0].Data)
+		result, found := results[method.RawName]
+		switch {
+		case mode == "limited":
+			rsp["error"] = map[string]interface{}{"code": -32005, "message": "rate limit exceeded"}
+		case mode == "plain" || !found:
+			rsp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+		default:
+			rsp["result"] = hexutil.Bytes(result)
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := &Token{Address: usdc, Name: "USD Coin", client: client}
+	ctx := context.Background()
+
+	if permitType, err := token.DetectPermit(ctx); err != nil || permitType != PermitEIP2612 {
+		t.Fatalf("expected EIP-2612, got %v %v", permitType, err)
+	}
+	// eip712Domain reverts, the version comes from version()
+	domain, err := token.permitDomain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain.Version != "2" || domain.Name != "USD Coin" {
+		t.Errorf("wrong domain %v", domain)
+	}
+
+	key, _ := crypto.GenerateKey()
+	account := &Account{PrivateKey: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	permit, err := account.SignPermit(ctx, token, common.HexToAddress("0x2222222222222222222222222222222222222222"), big.NewInt(5), big.NewInt(1700000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, err := RecoverTypedDataAddress(permit.TypedData(domain), permit.Signature()); err != nil || signer != account.Address || permit.Nonce.Int64() != 3 {
+		t.Errorf("wrong permit %+v: %v", permit, err)
+	}
+
+	mode = "plain"
+	if permitType, err := token.DetectPermit(ctx); err != nil || permitType != PermitUnsupported {
+		t.Errorf("expected unsupported, got %v %v", permitType, err)
+	}
+	// a provider error is not a token without permit
+	mode = "limited"
+	if _, err := token.DetectPermit(ctx); err == nil {
+		t.Error("expected the rate limit error")
+	}
+	if _, err := account.SignPermit(ctx, token, common.Address{}, big.NewInt(5), big.NewInt(1700000000)); err == nil || errors.Is(err, ErrPermitUnsupported) {
+		t.Errorf("expected the rate limit error, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/xerrors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// MinReplacementBump is the minimum fee increase in percent for a node to accept a replacement transaction.
+const MinReplacementBump = 10
+
+// ErrAlreadyMined is returned when replacing a transaction that is already mined.
+var ErrAlreadyMined = errors.New("transaction already mined")
+
+// SpeedUp sends tx again with the same nonce and fees raised by bumpPercent, at least MinReplacementBump,
+// and at least the fast fees currently suggested.
+func (account *Account) SpeedUp(client *Client, tx *types.Transaction, bumpPercent int) (*types.Transaction, error) {
+	return account.speedUp(client, tx, bumpPercent, nil)
+}
+
+func (account *Account) speedUp(client *Client, tx *types.Transaction, bumpPercent int, maxFeeCap *big.Int) (*types.Transaction, error) {
+	return account.replace(client, tx, bumpPercent, maxFeeCap, func(gasTipCap *big.Int, gasFeeCap *big.Int, chainID *big.Int) types.TxData {
+		return replacementData(tx, chainID, gasTipCap, gasFeeCap, tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList())
+	})
+}
+
+// Cancel replaces tx with an empty transfer to the account itself with the same nonce and bumped fees.
+func (account *Account) Cancel(client *Client, tx *types.Transaction) (*types.Transaction, error) {
+	to := account.Address
+	return account.replace(client, tx, MinReplacementBump, nil, func(gasTipCap *big.Int, gasFeeCap *big.Int, chainID *big.Int) types.TxData {
+		return replacementData(tx, chainID, gasTipCap, gasFeeCap, 21000, &to, new(big.Int), nil, nil)
+	})
+}
+
+// replacementData returns a transaction of the type of tx with its nonce and the given fields.
+// gasTipCap is nil for transactions with a legacy gas price, which is gasFeeCap.
+func replacementData(tx *types.Transaction, chainID *big.Int, gasTipCap *big.Int, gasFeeCap *big.Int, gas uint64, to *common.Address,
+	value *big.Int, data []byte, accessList types.AccessList) types.TxData {
+	switch {
+	case gasTipCap != nil:
+		return &types.DynamicFeeTx{ChainID: chainID, Nonce: tx.Nonce(), GasTipCap: gasTipCap, GasFeeCap: gasFeeCap, Gas: gas,
+			To: to, Value: value, Data: data, AccessList: accessList}
+	case tx.Type() == types.AccessListTxType:
+		return &types.AccessListTx{ChainID: chainID, Nonce: tx.Nonce(), GasPrice: gasFeeCap, Gas: gas,
+			To: to, Value: value, Data: data, AccessList: accessList}
+	default:
+		return &types.LegacyTx{Nonce: tx.Nonce(), GasPrice: gasFeeCap, Gas: gas, To: to, Value: value, Data: data}
+	}
+}
+
+// replace sends the transaction built by txData in place of tx. maxFeeCap, if set, caps the fee cap (or gas price)
+// raised to the fast fees; replace fails when the minimum bump is already above it.
+func (account *Account) replace(client *Client, tx *types.Transaction, bumpPercent int, maxFeeCap *big.Int, txData func(gasTipCap *big.Int, gasFeeCap *big.Int, chainID *big.Int) types.TxData) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if _, err := client.TransactionReceipt(ctx, tx.Hash()); err == nil {
+		return nil, ErrAlreadyMined
+	} else if !errors.Is(err, ethereum.NotFound) {
+		return nil, xerrors.Errorf("get receipt: %w", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get chain id: %w", err)
+	}
+
+	gasTipCap, gasFeeCap := BumpFees(tx, bumpPercent)
+	if maxFeeCap != nil && gasFeeCap.Cmp(maxFeeCap) > 0 {
+		return nil, xerrors.Errorf("fee cap %s above the maximum %s", gasFeeCap, maxFeeCap)
+	}
+	fees, err := account.suggestFees(ctx, client, FeeFast)
+	if err != nil {
+		return nil, err
+	}
+	if gasTipCap != nil && !fees.Legacy {
+		gasTipCap = maxBig(gasTipCap, fees.GasTipCap)
+	}
+	gasFeeCap = maxBig(gasFeeCap, fees.GasFeeCap)
+	if gasTipCap != nil && gasTipCap.Cmp(gasFeeCap) > 0 {
+		gasFeeCap = gasTipCap
+	}
+	if maxFeeCap != nil && gasFeeCap.Cmp(maxFeeCap) > 0 {
+		// the bumped fees fit, only the fast fees are cut down
+		gasFeeCap = maxFeeCap
+		if gasTipCap != nil && gasTipCap.Cmp(gasFeeCap) > 0 {
+			gasTipCap = gasFeeCap
+		}
+	}
+
+	signed, err := account.SignTransactionContext(ctx, types.NewTx(txData(gasTipCap, gasFeeCap, chainID)), chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.SendTransaction(ctx, signed); err != nil {
+		return nil, xerrors.Errorf("send replacement: %w", err)
+	}
+	if account.NonceManager != nil {
+		if err := account.NonceManager.Sent(account.Address, tx.Nonce(), signed.Hash()); err != nil {
+			return signed, err
+		}
+	}
+	return signed, nil
+}
+
+// BumpFees returns the fees of tx raised by bumpPercent, at least MinReplacementBump, rounding up.
+// The tip is nil for transactions with a legacy gas price, whose bumped gas price is returned as fee cap.
+func BumpFees(tx *types.Transaction, bumpPercent int) (gasTipCap *big.Int, gasFeeCap *big.Int) {
+	if bumpPercent < MinReplacementBump {
+		bumpPercent = MinReplacementBump
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		return bumpBig(tx.GasTipCap(), bumpPercent), bumpBig(tx.GasFeeCap(), bumpPercent)
+	}
+	return nil, bumpBig(tx.GasPrice(), bumpPercent)
+}
+
+func bumpBig(v *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(v, big.NewInt(int64(100+percent)))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBig(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// TxMonitor watches transactions of an account and speeds them up when they stay pending too long.
+type TxMonitor struct {
+	client  *Client
+	account *Account
+
+	// BumpAfter is how long a transaction may stay pending before it is sped up.
+	BumpAfter time.Duration
+	// BumpPercent is the fee increase of each speed up.
+	BumpPercent int
+	// MaxFeeCap, if set, is the highest fee cap (or gas price) a speed up may use. The fast fees are cut down to it,
+	// and a transaction whose minimum bump is above it is not sped up.
+	MaxFeeCap *big.Int
+	// PollInterval is the pause between checks of the pending transactions.
+	PollInterval time.Duration
+
+	// OnReplace, if set, is called after a transaction is replaced.
+	OnReplace func(old *types.Transaction, replacement *types.Transaction)
+	// OnMined, if set, is called when one of the versions of a transaction is mined.
+	OnMined func(tx *types.Transaction, receipt *types.Receipt)
+	// OnError, if set, is called with errors of the checks.
+	OnError func(err error)
+
+	lock    sync.Mutex
+	pending map[uint64]*monitoredTx
+}
+
+type monitoredTx struct {
+	// versions holds the transaction and its replacements, the latest last
+	versions []*types.Transaction
+	since    time.Time
+}
+
+func NewTxMonitor(client *Client, account *Account) *TxMonitor {
+	return &TxMonitor{
+		client:       client,
+		account:      account,
+		BumpAfter:    5 * time.Minute,
+		BumpPercent:  20,
+		PollInterval: 15 * time.Second,
+		pending:      map[uint64]*monitoredTx{},
+	}
+}
+
+// Track adds a sent transaction to the monitor.
+func (m *TxMonitor) Track(tx *types.Transaction) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.pending[tx.Nonce()] = &monitoredTx{versions: []*types.Transaction{tx}, since: time.Now()}
+}
+
+// Pending returns the latest version of every tracked transaction not mined yet.
+func (m *TxMonitor) Pending() []*types.Transaction {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	txs := make([]*types.Transaction, 0, len(m.pending))
+	for _, p := range m.pending {
+		txs = append(txs, p.versions[len(p.versions)-1])
+	}
+	return txs
+}
+
+// Run checks the tracked transactions every PollInterval until ctx is cancelled.
+func (m *TxMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check looks up the receipts of the tracked transactions once, and speeds up those pending longer than BumpAfter.
+// It is called by Run and must not run concurrently with itself.
+func (m *TxMonitor) Check(ctx context.Context) {
+	m.lock.Lock()
+	nonces := make([]uint64, 0, len(m.pending))
+	for nonce := range m.pending {
+		nonces = append(nonces, nonce)
+	}
+	m.lock.Unlock()
+
+	for _, nonce := range nonces {
+		if err := m.check(ctx, nonce); err != nil && m.OnError != nil {
+			m.OnError(err)
+		}
+	}
+}
+
+func (m *TxMonitor) check(ctx context.Context, nonce uint64) error {
+	m.lock.Lock()
+	p, ok := m.pending[nonce]
+	m.lock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	for _, tx := range p.versions {
+		receipt, err := m.client.TransactionReceipt(ctx, tx.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return xerrors.Errorf("get receipt of %s: %w", tx.Hash().Hex(), err)
+		}
+		m.remove(nonce)
+		if m.OnMined != nil {
+			m.OnMined(tx, receipt)
+		}
+		return nil
+	}
+
+	mined, err := m.client.NonceAt(ctx, m.account.Address, nil)
+	if err != nil {
+		return xerrors.Errorf("get nonce: %w", err)
+	}
+	if mined > nonce {
+		// replaced by a transaction sent elsewhere
+		m.remove(nonce)
+		return nil
+	}
+
+	if time.Since(p.since) < m.BumpAfter {
+		return nil
+	}
+	latest := p.versions[len(p.versions)-1]
+	replacement, err := m.account.speedUp(m.client, latest, m.BumpPercent, m.MaxFeeCap)
+	if errors.Is(err, ErrAlreadyMined) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("speed up %s: %w", latest.Hash().Hex(), err)
+	}
+
+	m.lock.Lock()
+	p.versions = append(p.versions, replacement)
+	p.since = time.Now()
+	m.lock.Unlock()
+	if m.OnReplace != nil {
+		m.OnReplace(latest, replacement)
+	}
+	return nil
+}
+
+func (m *TxMonitor) remove(nonce uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.pending, nonce)
+}
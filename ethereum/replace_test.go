@@ -0,0 +1,109 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestBumpFees(t *testing.T) {
+	legacy := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1001), Gas: 21000})
+	tip, price := BumpFees(legacy, 5)
+	if tip != nil {
+		t.Error("legacy transaction bumped with a tip")
+	}
+	// 1001 * 1.10 = 1101.1, rounded up
+	if price.Int64() != 1102 {
+		t.Errorf("wrong gas price %s", price)
+	}
+
+	dynamic := types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(2000000000), GasFeeCap: big.NewInt(100000000000), Gas: 21000})
+	tip, feeCap := BumpFees(dynamic, 25)
+	if tip.Int64() != 2500000000 || feeCap.Int64() != 125000000000 {
+		t.Errorf("wrong fees %s %s", tip, feeCap)
+	}
+}
+
+func TestTxMonitor_SpeedUp(t *testing.T) {
+	var lock sync.Mutex
+	var sent []*types.Transaction
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		lock.Lock()
+		defer lock.Unlock()
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_chainId":
+			rsp["result"] = "0x1"
+		case "eth_getTransactionReceipt":
+			rsp["result"] = nil
+		case "eth_getTransactionCount":
+			rsp["result"] = "0x0"
+		case "eth_getBlockByNumber":
+			// fast fees of 2 gwei + 2 * 50 gwei
+			rsp["result"] = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), BaseFee: big.NewInt(50000000000)}
+		case "eth_maxPriorityFeePerGas":
+			rsp["result"] = "0x77359400"
+		case "eth_sendRawTransaction":
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(req.Params[0], &raw)
+			tx := new(types.Transaction)
+			_ = tx.UnmarshalBinary(raw)
+			sent = append(sent, tx)
+			rsp["result"] = tx.Hash()
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.GenerateKey()
+	account := AccountFromPrivateKey(key)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+	tx, err := account.SignTransaction(types.NewTx(&types.AccessListTx{ChainID: big.NewInt(1), Nonce: 3, GasPrice: big.NewInt(60000000000),
+		Gas: 50000, To: &to, Value: big.NewInt(1), AccessList: accessList}), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	monitor := NewTxMonitor(client, account)
+	monitor.BumpAfter = 0
+	monitor.OnError = func(err error) { errs = append(errs, err) }
+	// the fast fees of 102 gwei are cut down to the maximum
+	monitor.MaxFeeCap = big.NewInt(80000000000)
+	monitor.Track(tx)
+	monitor.Check(context.Background())
+	if len(errs) > 0 || len(sent) != 1 {
+		t.Fatalf("expected a replacement, got %v", errs)
+	}
+	replacement := sent[0]
+	if replacement.Type() != types.AccessListTxType || len(replacement.AccessList()) != 1 || replacement.Nonce() != 3 {
+		t.Errorf("replacement lost the type or access list: type %d, %v", replacement.Type(), replacement.AccessList())
+	}
+	if replacement.GasPrice().Cmp(monitor.MaxFeeCap) != 0 {
+		t.Errorf("expected gas price %s, got %s", monitor.MaxFeeCap, replacement.GasPrice())
+	}
+
+	// the minimum bump of 96 gwei is above the maximum
+	monitor.Check(context.Background())
+	if len(errs) != 1 || len(sent) != 1 {
+		t.Errorf("expected no replacement above the maximum, got %d sent, errors %v", len(sent), errs)
+	}
+}
@@ -0,0 +1,80 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignHash signs a 32-byte digest and returns the 65-byte [R || S || V] signature with V as 27 or 28.
+func (account *Account) SignHash(hash []byte) ([]byte, error) {
+	return account.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext is SignHash with a context for the Signer of the account.
+func (account *Account) SignHashContext(ctx context.Context, hash []byte) ([]byte, error) {
+	sig, err := account.signer().SignDigest(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// SignMessage signs message as personal_sign (EIP-191 version 0x45) does,
+// over keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
+func (account *Account) SignMessage(message []byte) ([]byte, error) {
+	return account.SignMessageContext(context.Background(), message)
+}
+
+// SignMessageContext is SignMessage with a context for the Signer of the account.
+func (account *Account) SignMessageContext(ctx context.Context, message []byte) ([]byte, error) {
+	return account.SignHashContext(ctx, accounts.TextHash(message))
+}
+
+// SignTypedData signs an EIP-712 typed data document as eth_signTypedData_v4 does.
+func (account *Account) SignTypedData(typedData *TypedData) ([]byte, error) {
+	return account.SignTypedDataContext(context.Background(), typedData)
+}
+
+// SignTypedDataContext is SignTypedData with a context for the Signer of the account.
+func (account *Account) SignTypedDataContext(ctx context.Context, typedData *TypedData) ([]byte, error) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return account.SignHashContext(ctx, hash[:])
+}
+
+// RecoverHashAddress returns the address that signed hash. V may be 0/1 or 27/28.
+func RecoverHashAddress(hash []byte, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("signature must be 65 bytes long")
+	}
+	s := make([]byte, crypto.SignatureLength)
+	copy(s, sig)
+	if s[crypto.RecoveryIDOffset] >= 27 {
+		s[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(hash, s)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// RecoverAddress returns the address that signed message with personal_sign.
+func RecoverAddress(message []byte, sig []byte) (common.Address, error) {
+	return RecoverHashAddress(accounts.TextHash(message), sig)
+}
+
+// RecoverTypedDataAddress returns the address that signed the EIP-712 typed data document.
+func RecoverTypedDataAddress(typedData *TypedData, sig []byte) (common.Address, error) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return RecoverHashAddress(hash[:], sig)
+}
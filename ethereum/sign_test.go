@@ -0,0 +1,99 @@
+package ethereum
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+	"testing"
+)
+
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+// test vector of the EIP-712 specification
+func TestSignTypedData(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := typedData.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash.Hex() != "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Errorf("wrong hash %s", hash.Hex())
+	}
+
+	key := crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))
+	account := &Account{PrivateKey: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	sig, err := account.SignTypedData(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(sig) != "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c" {
+		t.Errorf("wrong signature %s", hexutil.Encode(sig))
+	}
+	addr, err := RecoverTypedDataAddress(typedData, sig)
+	if err != nil || addr != account.Address {
+		t.Errorf("wrong recovered address %s: %v", addr.Hex(), err)
+	}
+
+	// wallets also send the chain id as a hex string
+	typedData, err = ParseTypedData([]byte(strings.Replace(mailTypedData, `"chainId": 1`, `"chainId": "0x1"`, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash2, err := typedData.Hash(); err != nil || hash2 != hash {
+		t.Errorf("wrong hash with hex chain id %s: %v", hash2.Hex(), err)
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	wallet, _ := NewWallet("tag volcano eight thank tide danger coast health above argue embrace heavy")
+	account, _ := wallet.DeriveAccount(DerivePath(0, 0))
+
+	sig, err := account.SignMessage([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Errorf("wrong v %d", sig[64])
+	}
+	addr, err := RecoverAddress([]byte("hello"), sig)
+	if err != nil || addr != account.Address {
+		t.Errorf("wrong recovered address %s: %v", addr.Hex(), err)
+	}
+	addr, _ = RecoverAddress([]byte("hello!"), sig)
+	if addr == account.Address {
+		t.Error("signature should not match another message")
+	}
+}
@@ -0,0 +1,98 @@
+package ethereum
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/icodeface/chain-kit/remotesigner"
+	"golang.org/x/xerrors"
+)
+
+// Signer holds the secp256k1 key of an account, in memory, in an HSM or in a remote service.
+type Signer interface {
+	PublicKey() *ecdsa.PublicKey
+	// SignDigest signs a 32-byte digest and returns the 65-byte [R || S || V] signature with V as 0 or 1.
+	SignDigest(ctx context.Context, digest []byte) ([]byte, error)
+}
+
+// KeySigner signs with a private key in memory.
+type KeySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (s *KeySigner) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+func (s *KeySigner) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	return crypto.Sign(digest, s.key)
+}
+
+// RemoteSigner signs with a key of a remote signer, see package remotesigner.
+type RemoteSigner struct {
+	client    *remotesigner.Client
+	id        string
+	publicKey *ecdsa.PublicKey
+}
+
+// NewRemoteSigner fetches the public key of the secp256k1 key id from the remote signer.
+func NewRemoteSigner(ctx context.Context, client *remotesigner.Client, id string) (*RemoteSigner, error) {
+	keyType, key, err := client.PublicKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if keyType != remotesigner.KeyTypeSecp256k1 {
+		return nil, xerrors.Errorf("key %s is %s, not secp256k1", id, keyType)
+	}
+	pub, err := crypto.UnmarshalPubkey(key)
+	if err != nil {
+		return nil, xerrors.Errorf("decode public key: %w", err)
+	}
+	return &RemoteSigner{client: client, id: id, publicKey: pub}, nil
+}
+
+func (s *RemoteSigner) PublicKey() *ecdsa.PublicKey {
+	return s.publicKey
+}
+
+// SignDigest signs remotely and checks that the signature recovers to the public key.
+func (s *RemoteSigner) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	sig, err := s.client.Sign(ctx, s.id, digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, xerrors.Errorf("invalid signature length %d", len(sig))
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid signature: %w", err)
+	}
+	if crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(*s.publicKey) {
+		return nil, xerrors.New("signature does not match the remote key")
+	}
+	return sig, nil
+}
+
+// ErrNoPrivateKey is returned when exporting the key of an account created by AccountFromSigner.
+var ErrNoPrivateKey = errors.New("account has no private key")
+
+// AccountFromSigner creates an account signing with signer. Its PrivateKey is nil.
+func AccountFromSigner(signer Signer) *Account {
+	return &Account{
+		Address: crypto.PubkeyToAddress(*signer.PublicKey()),
+		Signer:  signer,
+	}
+}
+
+func (account *Account) signer() Signer {
+	if account.Signer != nil {
+		return account.Signer
+	}
+	return NewKeySigner(account.PrivateKey)
+}
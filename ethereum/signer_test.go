@@ -0,0 +1,81 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/icodeface/chain-kit/remotesigner"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	account, err := AccountFromHex("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := remotesigner.NewServer([]byte("secret"))
+	server.AddSecp256k1Key("hot", account.PrivateKey)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	signer, err := NewRemoteSigner(context.Background(), remotesigner.NewClient(ts.URL, []byte("secret")), "hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := AccountFromSigner(signer)
+	if remote.Address != account.Address || remote.PrivateKey != nil {
+		t.Fatalf("wrong remote account %s", remote.Address.Hex())
+	}
+
+	// signatures are deterministic, the remote and the in-memory keys must agree
+	local, err := account.SignMessage([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := remote.SignMessage([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, local) {
+		t.Errorf("remote signature %x, local %x", sig, local)
+	}
+	if addr, err := RecoverAddress([]byte("hello"), sig); err != nil || addr != account.Address {
+		t.Errorf("signature recovers to %s", addr.Hex())
+	}
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+	signedLocal, err := account.SignTransaction(tx, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedRemote, err := remote.SignTransaction(tx, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedRemote.Hash() != signedLocal.Hash() {
+		t.Errorf("remote transaction %s, local %s", signedRemote.Hash().Hex(), signedLocal.Hash().Hex())
+	}
+
+	// the context of the caller reaches the remote signer
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := remote.SignTransactionContext(cancelled, tx, big.NewInt(1)); err == nil {
+		t.Error("expected a cancelled context error")
+	}
+
+	if _, err := NewRemoteSigner(context.Background(), remotesigner.NewClient(ts.URL, []byte("wrong")), "hot"); err == nil {
+		t.Error("expected authentication error")
+	}
+}
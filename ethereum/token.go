@@ -0,0 +1,167 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+)
+
+// ErrNonZeroAllowance is returned by ApproveToken when changing an allowance from one non-zero value to another,
+// which lets the spender front-run the approval and spend both allowances.
+var ErrNonZeroAllowance = errors.New("approve from non-zero to non-zero allowance, reset it to zero first or use IncreaseTokenAllowance")
+
+// allowanceABI holds the OpenZeppelin allowance extension, not part of the ERC-20 standard.
+const allowanceABI = `[{"inputs":[{"name":"spender","type":"address"},{"name":"addedValue","type":"uint256"}],"name":"increaseAllowance","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"name":"spender","type":"address"},{"name":"subtractedValue","type":"uint256"}],"name":"decreaseAllowance","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"}]`
+
+// Token is an ERC-20 token with its metadata, read once when it is created.
+type Token struct {
+	Address  common.Address
+	Name     string
+	Symbol   string
+	Decimals uint8
+
+	client   *Client
+	contract *ERC20
+}
+
+// NewToken binds the ERC-20 contract at address and reads its name, symbol and decimals.
+func NewToken(ctx context.Context, client *Client, address common.Address) (*Token, error) {
+	contract, err := NewERC20(address, client)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	decimals, err := contract.Decimals(opts)
+	if err != nil {
+		return nil, xerrors.Errorf("get decimals of %s: %w", address.Hex(), err)
+	}
+	// some tokens (e.g. MKR) return bytes32 and fail to decode, the metadata is informational only
+	name, _ := contract.Name(opts)
+	symbol, _ := contract.Symbol(opts)
+	return &Token{
+		Address:  address,
+		Name:     name,
+		Symbol:   symbol,
+		Decimals: decimals,
+		client:   client,
+		contract: contract,
+	}, nil
+}
+
+// Contract returns the underlying binding.
+func (t *Token) Contract() *ERC20 {
+	return t.contract
+}
+
+// ToDecimal converts an amount in base units to tokens.
+func (t *Token) ToDecimal(v *big.Int) decimal.Decimal {
+	return decimal.NewFromBigInt(v, -int32(t.Decimals))
+}
+
+// FromDecimal converts an amount in tokens to base units. It fails if v has more fractional digits than the token.
+func (t *Token) FromDecimal(v decimal.Decimal) (*big.Int, error) {
+	scaled := v.Shift(int32(t.Decimals))
+	if !scaled.Equal(scaled.Truncate(0)) {
+		return nil, xerrors.Errorf("%s has more than %d decimals", v, t.Decimals)
+	}
+	return scaled.BigInt(), nil
+}
+
+// BalanceOf returns the token balance of owner in base units.
+func (t *Token) BalanceOf(ctx context.Context, owner common.Address) (*big.Int, error) {
+	return t.contract.BalanceOf(&bind.CallOpts{Context: ctx}, owner)
+}
+
+// Allowance returns the amount spender may transfer from owner in base units.
+func (t *Token) Allowance(ctx context.Context, owner common.Address, spender common.Address) (*big.Int, error) {
+	return t.contract.Allowance(&bind.CallOpts{Context: ctx}, owner, spender)
+}
+
+// TransferToken transfers amount base units of the token to the address to, after checking the balance.
+func (account *Account) TransferToken(ctx context.Context, token *Token, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	if amount.Sign() <= 0 {
+		return nil, errors.New("invalid value")
+	}
+	balance, err := token.BalanceOf(ctx, account.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("get token balance: %w", err)
+	}
+	if balance.Cmp(amount) < 0 {
+		return nil, xerrors.Errorf("not enough %s: %s < %s", token.Symbol, token.ToDecimal(balance), token.ToDecimal(amount))
+	}
+	return account.Transact(ctx, token.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.contract.Transfer(opts, to, amount)
+	})
+}
+
+// TransferTokenFrom transfers amount base units of the token from the address from to the address to,
+// after checking the balance of from and the allowance it granted to the account.
+func (account *Account) TransferTokenFrom(ctx context.Context, token *Token, from common.Address, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	if amount.Sign() <= 0 {
+		return nil, errors.New("invalid value")
+	}
+	balance, err := token.BalanceOf(ctx, from)
+	if err != nil {
+		return nil, xerrors.Errorf("get token balance: %w", err)
+	}
+	if balance.Cmp(amount) < 0 {
+		return nil, xerrors.Errorf("not enough %s: %s < %s", token.Symbol, token.ToDecimal(balance), token.ToDecimal(amount))
+	}
+	allowance, err := token.Allowance(ctx, from, account.Address)
+	if err != nil {
+		return nil, xerrors.Errorf("get allowance: %w", err)
+	}
+	if allowance.Cmp(amount) < 0 {
+		return nil, xerrors.Errorf("not enough allowance: %s < %s", token.ToDecimal(allowance), token.ToDecimal(amount))
+	}
+	return account.Transact(ctx, token.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.contract.TransferFrom(opts, from, to, amount)
+	})
+}
+
+// ApproveToken sets the allowance of spender. Like SafeERC20.safeApprove it refuses to change a non-zero
+// allowance to another non-zero value and returns ErrNonZeroAllowance.
+func (account *Account) ApproveToken(ctx context.Context, token *Token, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	if amount.Sign() > 0 {
+		allowance, err := token.Allowance(ctx, account.Address, spender)
+		if err != nil {
+			return nil, xerrors.Errorf("get allowance: %w", err)
+		}
+		if allowance.Sign() > 0 {
+			return nil, ErrNonZeroAllowance
+		}
+	}
+	return account.Transact(ctx, token.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.contract.Approve(opts, spender, amount)
+	})
+}
+
+// IncreaseTokenAllowance adds amount to the allowance of spender with increaseAllowance.
+// Only tokens implementing the OpenZeppelin extension support it.
+func (account *Account) IncreaseTokenAllowance(ctx context.Context, token *Token, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	return account.changeTokenAllowance(ctx, token, "increaseAllowance", spender, amount)
+}
+
+// DecreaseTokenAllowance subtracts amount from the allowance of spender with decreaseAllowance.
+// Only tokens implementing the OpenZeppelin extension support it.
+func (account *Account) DecreaseTokenAllowance(ctx context.Context, token *Token, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	return account.changeTokenAllowance(ctx, token, "decreaseAllowance", spender, amount)
+}
+
+func (account *Account) changeTokenAllowance(ctx context.Context, token *Token, method string, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	parsed, err := abi.JSON(strings.NewReader(allowanceABI))
+	if err != nil {
+		return nil, err
+	}
+	contract := bind.NewBoundContract(token.Address, parsed, token.client, token.client, token.client)
+	return account.Transact(ctx, token.client, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.Transact(opts, method, spender, amount)
+	})
+}
@@ -0,0 +1,334 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestToken_Decimal(t *testing.T) {
+	usdt := &Token{Symbol: "USDT", Decimals: 6}
+
+	v, err := usdt.FromDecimal(decimal.RequireFromString("12.345678"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Cmp(big.NewInt(12345678)) != 0 {
+		t.Errorf("wrong base units %s", v)
+	}
+	if usdt.ToDecimal(v).String() != "12.345678" {
+		t.Errorf("wrong decimal %s", usdt.ToDecimal(v))
+	}
+	if _, err := usdt.FromDecimal(decimal.RequireFromString("0.0000001")); err == nil {
+		t.Error("expected error for too many decimals")
+	}
+}
+
+// erc20Node is a JSON-RPC node of a legacy chain holding one ERC-20 token. Sent transactions are applied at once.
+type erc20Node struct {
+	t          *testing.T
+	abi        abi.ABI
+	lock       sync.Mutex
+	nonces     map[common.Address]uint64
+	balances   map[common.Address]*big.Int
+	allowances map[[2]common.Address]*big.Int
+	sent       []string
+}
+
+func newERC20Node(t *testing.T) *erc20Node {
+	parsed, err := ERC20MetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	extension, err := abi.JSON(strings.NewReader(allowanceABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, method := range extension.Methods {
+		parsed.Methods[name] = method
+	}
+	return &erc20Node{
+		t:          t,
+		abi:        *parsed,
+		nonces:     map[common.Address]uint64{},
+		balances:   map[common.Address]*big.Int{},
+		allowances: map[[2]common.Address]*big.Int{},
+	}
+}
+
+func (n *erc20Node) balance(owner common.Address) *big.Int {
+	if v, ok := n.balances[owner]; ok {
+		return v
+	}
+	return new(big.Int)
+}
+
+func (n *erc20Node) allowance(owner common.Address, spender common.Address) *big.Int {
+	if v, ok := n.allowances[[2]common.Address{owner, spender}]; ok {
+		return v
+	}
+	return new(big.Int)
+}
+
+// call runs method of the token for the sender from, it returns the packed outputs or false if it reverts.
+func (n *erc20Node) call(from common.Address, data []byte) ([]byte, bool) {
+	method, err := n.abi.MethodById(data)
+	if err != nil {
+		return nil, false
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, false
+	}
+	var outputs []interface{}
+	switch method.Name {
+	case "name":
+		outputs = []interface{}{"Tether USD"}
+	case "symbol":
+		outputs = []interface{}{"USDT"}
+	case "decimals":
+		outputs = []interface{}{uint8(6)}
+	case "balanceOf":
+		outputs = []interface{}{n.balance(args[0].(common.Address))}
+	case "allowance":
+		outputs = []interface{}{n.allowance(args[0].(common.Address), args[1].(common.Address))}
+	case "transfer", "transferFrom":
+		owner := from
+		if method.Name == "transferFrom" {
+			owner, args = args[0].(common.Address), args[1:]
+		}
+		to, amount := args[0].(common.Address), args[1].(*big.Int)
+		if method.Name == "transferFrom" {
+			allowance := n.allowance(owner, from)
+			if allowance.Cmp(amount) < 0 {
+				return nil, false
+			}
+			n.allowances[[2]common.Address{owner, from}] = new(big.Int).Sub(allowance, amount)
+		}
+		if n.balance(owner).Cmp(amount) < 0 {
+			return nil, false
+		}
+		n.balances[owner] = new(big.Int).Sub(n.balance(owner), amount)
+		n.balances[to] = new(big.Int).Add(n.balance(to), amount)
+		outputs = []interface{}{true}
+	case "approve":
+		n.allowances[[2]common.Address{from, args[0].(common.Address)}] = args[1].(*big.Int)
+		outputs = []interface{}{true}
+	case "increaseAllowance":
+		spender := args[0].(common.Address)
+		n.allowances[[2]common.Address{from, spender}] = new(big.Int).Add(n.allowance(from, spender), args[1].(*big.Int))
+		outputs = []interface{}{true}
+	case "decreaseAllowance":
+		spender := args[0].(common.Address)
+		allowance := n.allowance(from, spender)
+		if allowance.Cmp(args[1].(*big.Int)) < 0 {
+			return nil, false
+		}
+		n.allowances[[2]common.Address{from, spender}] = new(big.Int).Sub(allowance, args[1].(*big.Int))
+		outputs = []interface{}{true}
+	default:
+		return nil, false
+	}
+	out, err := method.Outputs.Pack(outputs...)
+	if err != nil {
+		n.t.Error(err)
+	}
+	return out, true
+}
+
+func (n *erc20Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     interface{}       `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "eth_chainId":
+		rsp["result"] = "0x1"
+	case "eth_getBlockByNumber":
+		rsp["result"] = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	case "eth_gasPrice":
+		rsp["result"] = "0x3b9aca00"
+	case "eth_getCode":
+		rsp["result"] = "0x60"
+	case "eth_estimateGas":
+		rsp["result"] = "0xea60"
+	case "eth_getTransactionCount":
+		var address common.Address
+		_ = json.Unmarshal(req.Params[0], &address)
+		rsp["result"] = hexutil.Uint64(n.nonces[address])
+	case "eth_call":
+		var msg struct {
+			From  common.Address `json:"from"`
+			Data  hexutil.Bytes  `json:"data"`
+			Input hexutil.Bytes  `json:"input"`
+		}
+		_ = json.Unmarshal(req.Params[0], &msg)
+		data := msg.Data
+		if len(data) == 0 {
+			data = msg.Input
+		}
+		// calls must not change the state
+		balances, allowances := n.balances, n.allowances
+		n.balances, n.allowances = map[common.Address]*big.Int{}, map[[2]common.Address]*big.Int{}
+		for k, v := range balances {
+			n.balances[k] = v
+		}
+		for k, v := range allowances {
+			n.allowances[k] = v
+		}
+		out, ok := n.call(msg.From, data)
+		n.balances, n.allowances = balances, allowances
+		if !ok {
+			rsp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			break
+		}
+		rsp["result"] = hexutil.Bytes(out)
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		_ = json.Unmarshal(req.Params[0], &raw)
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			n.t.Error(err)
+			break
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			n.t.Error(err)
+			break
+		}
+		n.nonces[from]++
+		method, err := n.abi.MethodById(tx.Data())
+		if err != nil {
+			n.t.Error(err)
+			break
+		}
+		n.sent = append(n.sent, method.Name)
+		// a reverted transaction is mined too
+		_, _ = n.call(from, tx.Data())
+		rsp["result"] = tx.Hash()
+	}
+	_ = json.NewEncoder(w).Encode(rsp)
+}
+
+func newTokenTest(t *testing.T) (*erc20Node, *Token, *Account) {
+	node := newERC20Node(t)
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewToken(context.Background(), client, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Symbol != "USDT" || token.Decimals != 6 {
+		t.Fatalf("wrong token metadata %s %d", token.Symbol, token.Decimals)
+	}
+	key, _ := crypto.GenerateKey()
+	return node, token, AccountFromPrivateKey(key)
+}
+
+func TestAccount_TransferToken(t *testing.T) {
+	node, token, account := newTokenTest(t)
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	node.balances[account.Address] = big.NewInt(1000)
+	ctx := context.Background()
+
+	if _, err := account.TransferToken(ctx, token, to, big.NewInt(1001)); err == nil || !strings.Contains(err.Error(), "not enough USDT") {
+		t.Errorf("expected not enough USDT, got %v", err)
+	}
+	if _, err := account.TransferToken(ctx, token, to, big.NewInt(0)); err == nil {
+		t.Error("expected invalid value")
+	}
+	if len(node.sent) != 0 {
+		t.Fatalf("unexpected transactions %v", node.sent)
+	}
+	if _, err := account.TransferToken(ctx, token, to, big.NewInt(400)); err != nil {
+		t.Fatal(err)
+	}
+	if node.balance(account.Address).Int64() != 600 || node.balance(to).Int64() != 400 {
+		t.Errorf("wrong balances %s %s", node.balance(account.Address), node.balance(to))
+	}
+}
+
+func TestAccount_TransferTokenFrom(t *testing.T) {
+	node, token, account := newTokenTest(t)
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	node.balances[owner] = big.NewInt(1000)
+	node.allowances[[2]common.Address{owner, account.Address}] = big.NewInt(300)
+	ctx := context.Background()
+
+	if _, err := account.TransferTokenFrom(ctx, token, owner, to, big.NewInt(301)); err == nil || !strings.Contains(err.Error(), "not enough allowance") {
+		t.Errorf("expected not enough allowance, got %v", err)
+	}
+	if _, err := account.TransferTokenFrom(ctx, token, owner, to, big.NewInt(1001)); err == nil || !strings.Contains(err.Error(), "not enough USDT") {
+		t.Errorf("expected not enough USDT, got %v", err)
+	}
+	if len(node.sent) != 0 {
+		t.Fatalf("unexpected transactions %v", node.sent)
+	}
+	if _, err := account.TransferTokenFrom(ctx, token, owner, to, big.NewInt(300)); err != nil {
+		t.Fatal(err)
+	}
+	if node.balance(owner).Int64() != 700 || node.balance(to).Int64() != 300 || node.allowance(owner, account.Address).Sign() != 0 {
+		t.Errorf("wrong state: balances %s %s, allowance %s", node.balance(owner), node.balance(to), node.allowance(owner, account.Address))
+	}
+}
+
+func TestAccount_ApproveToken(t *testing.T) {
+	node, token, account := newTokenTest(t)
+	spender := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	ctx := context.Background()
+
+	if _, err := account.ApproveToken(ctx, token, spender, big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	// changing a non-zero allowance to another non-zero value is refused
+	if _, err := account.ApproveToken(ctx, token, spender, big.NewInt(50)); err != ErrNonZeroAllowance {
+		t.Errorf("expected ErrNonZeroAllowance, got %v", err)
+	}
+	if node.allowance(account.Address, spender).Int64() != 100 || len(node.sent) != 1 {
+		t.Fatalf("wrong allowance %s after %v", node.allowance(account.Address, spender), node.sent)
+	}
+	if _, err := account.ApproveToken(ctx, token, spender, big.NewInt(0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := account.ApproveToken(ctx, token, spender, big.NewInt(50)); err != nil {
+		t.Fatal(err)
+	}
+	if node.allowance(account.Address, spender).Int64() != 50 {
+		t.Errorf("wrong allowance %s", node.allowance(account.Address, spender))
+	}
+
+	if _, err := account.IncreaseTokenAllowance(ctx, token, spender, big.NewInt(25)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := account.DecreaseTokenAllowance(ctx, token, spender, big.NewInt(10)); err != nil {
+		t.Fatal(err)
+	}
+	if node.allowance(account.Address, spender).Int64() != 65 {
+		t.Errorf("wrong allowance %s", node.allowance(account.Address, spender))
+	}
+	expected := []string{"approve", "approve", "approve", "increaseAllowance", "decreaseAllowance"}
+	if strings.Join(node.sent, ",") != strings.Join(expected, ",") {
+		t.Errorf("wrong transactions %v", node.sent)
+	}
+}
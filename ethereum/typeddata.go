@@ -0,0 +1,107 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+// TypedDataField is a member of an EIP-712 struct type.
+type TypedDataField = core.Type
+
+// TypedDataDomain is the EIP712Domain of a typed data document.
+type TypedDataDomain = core.TypedDataDomain
+
+// TypedData is an EIP-712 typed data document, as passed to eth_signTypedData_v4.
+// The encoding is go-ethereum's: integers of the message are *math.HexOrDecimal256 or decimal/hex strings,
+// addresses and bytes are hex strings.
+type TypedData struct {
+	core.TypedData
+}
+
+// ParseTypedData decodes an EIP-712 typed data JSON document.
+func ParseTypedData(data []byte) (*TypedData, error) {
+	var raw struct {
+		Types       core.Types `json:"types"`
+		PrimaryType string     `json:"primaryType"`
+		Domain      struct {
+			core.TypedDataDomain
+			// wallets send the chain id as a number or a string
+			ChainId json.RawMessage `json:"chainId"`
+		} `json:"domain"`
+		Message map[string]interface{} `json:"message"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep large integers exact
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, xerrors.Errorf("decode typed data: %w", err)
+	}
+	if raw.PrimaryType == "" {
+		return nil, xerrors.New("typed data has no primary type")
+	}
+
+	typedData := &TypedData{core.TypedData{
+		Types:       raw.Types,
+		PrimaryType: raw.PrimaryType,
+		Domain:      raw.Domain.TypedDataDomain,
+		Message:     typedDataNumbers(raw.Message).(map[string]interface{}),
+	}}
+	if chainID := strings.Trim(string(raw.Domain.ChainId), `"`); chainID != "" && chainID != "null" {
+		typedData.Domain.ChainId = new(math.HexOrDecimal256)
+		if err := typedData.Domain.ChainId.UnmarshalText([]byte(chainID)); err != nil {
+			return nil, xerrors.Errorf("decode chain id: %w", err)
+		}
+	}
+	return typedData, nil
+}
+
+// typedDataNumbers turns the json.Number values of a decoded message into strings, which go-ethereum parses exactly.
+func typedDataNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = typedDataNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = typedDataNumbers(item)
+		}
+	}
+	return value
+}
+
+// Hash returns the EIP-712 signing hash keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message)).
+func (typedData *TypedData) Hash() (common.Hash, error) {
+	domainSeparator, err := typedData.DomainSeparator()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if typedData.PrimaryType == "EIP712Domain" {
+		return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator[:]), nil
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, xerrors.Errorf("hash %s: %w", typedData.PrimaryType, err)
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator[:], messageHash), nil
+}
+
+// DomainSeparator returns hashStruct(EIP712Domain).
+func (typedData *TypedData) DomainSeparator() (common.Hash, error) {
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		return common.Hash{}, xerrors.New("typed data has no EIP712Domain type")
+	}
+	hash, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, xerrors.Errorf("hash EIP712Domain: %w", err)
+	}
+	return common.BytesToHash(hash), nil
+}
@@ -0,0 +1,221 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// WaitPollInterval is the pause between receipt checks of WaitMined.
+var WaitPollInterval = 3 * time.Second
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicReasons describes the Solidity panic codes.
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized function",
+}
+
+// RevertError is returned by WaitMined for transactions mined with a failed status.
+type RevertError struct {
+	Receipt *types.Receipt
+	// Data is the raw revert data, empty if the replay did not return any.
+	Data []byte
+	// Reason is the decoded revert reason, empty if it could not be decoded.
+	Reason string
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("transaction %s reverted", e.Receipt.TxHash.Hex())
+	}
+	return fmt.Sprintf("transaction %s reverted: %s", e.Receipt.TxHash.Hex(), e.Reason)
+}
+
+// WaitMined waits until the transaction is mined and confirmations blocks deep (1 is the mining block itself)
+// and returns its receipt. If a reorg removes the block, the transaction is looked up again.
+// Failed transactions return the receipt and a *RevertError, with the reason decoded from the replayed call.
+// Custom errors are decoded with the error definitions of the ABIs.
+func WaitMined(ctx context.Context, client *Client, txHash common.Hash, confirmations uint64, abis ...*abi.ABI) (*types.Receipt, error) {
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	for {
+		receipt, err := confirmedReceipt(ctx, client, txHash, confirmations)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			if receipt.Status == types.ReceiptStatusFailed {
+				return receipt, revertError(ctx, client, receipt, abis)
+			}
+			return receipt, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(WaitPollInterval):
+		}
+	}
+}
+
+// confirmedReceipt returns the receipt if it is deep enough and still on the canonical chain, nil otherwise.
+func confirmedReceipt(ctx context.Context, client *Client, txHash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	receipt, err := client.TransactionReceipt(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("get receipt: %w", err)
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("get block number: %w", err)
+	}
+	mined := receipt.BlockNumber.Uint64()
+	if head < mined || head-mined+1 < confirmations {
+		return nil, nil
+	}
+	header, err := client.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return nil, xerrors.Errorf("get header %d: %w", mined, err)
+	}
+	if header.Hash() != receipt.BlockHash {
+		// reorged out, the node may still return the stale receipt
+		return nil, nil
+	}
+	return receipt, nil
+}
+
+// revertError replays the failed transaction on the state before its block to get the revert data. The replay
+// doesn't apply the transactions mined before it in the same block, so a revert depending on them may not reproduce.
+func revertError(ctx context.Context, client *Client, receipt *types.Receipt, abis []*abi.ABI) error {
+	revertErr := &RevertError{Receipt: receipt}
+	tx, _, err := client.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return revertErr
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return revertErr
+	}
+
+	_, err = client.CallContract(ctx, ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}, parentBlock(receipt.BlockNumber))
+	if err == nil {
+		// reverted for reasons not reproduced by the replay, e.g. out of gas
+		return revertErr
+	}
+	revertErr.Data = revertData(err)
+	revertErr.Reason = DecodeRevertReason(revertErr.Data, abis...)
+	if revertErr.Reason == "" && len(revertErr.Data) == 0 {
+		revertErr.Reason = strings.TrimPrefix(err.Error(), "execution reverted: ")
+	}
+	return revertErr
+}
+
+// parentBlock returns the number of the block before number, whose state the transactions of number run on.
+func parentBlock(number *big.Int) *big.Int {
+	if number.Sign() <= 0 {
+		return number
+	}
+	return new(big.Int).Sub(number, big.NewInt(1))
+}
+
+func revertData(err error) []byte {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil
+	}
+	s, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil
+	}
+	data, err := hexutil.Decode(s)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// DecodeRevertReason decodes revert data as Error(string), Panic(uint256) or a custom error of the ABIs.
+// It returns an empty string if the data matches none of them.
+func DecodeRevertReason(data []byte, abis ...*abi.ABI) string {
+	if len(data) < 4 {
+		return ""
+	}
+	selector := data[:4]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		reason, err := abi.UnpackRevert(data)
+		if err != nil {
+			return ""
+		}
+		return reason
+	case bytes.Equal(selector, panicSelector):
+		if len(data) != 36 {
+			return ""
+		}
+		code := new(big.Int).SetBytes(data[4:])
+		if code.IsUint64() {
+			if reason, ok := panicReasons[code.Uint64()]; ok {
+				return fmt.Sprintf("panic 0x%x: %s", code, reason)
+			}
+		}
+		return fmt.Sprintf("panic 0x%x", code)
+	}
+	for _, a := range abis {
+		if a == nil {
+			continue
+		}
+		for _, e := range a.Errors {
+			if !bytes.Equal(selector, e.ID[:4]) {
+				continue
+			}
+			args, err := e.Unpack(data)
+			if err != nil {
+				continue
+			}
+			values := make([]string, len(e.Inputs))
+			for i, input := range e.Inputs {
+				values[i] = fmt.Sprintf("%s=%v", input.Name, args.([]interface{})[i])
+			}
+			return fmt.Sprintf("%s(%s)", e.Name, strings.Join(values, ", "))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDecodeRevertReason(t *testing.T) {
+	// require(false, "Not enough Ether provided.")
+	data := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001a" +
+		"4e6f7420656e6f7567682045746865722070726f76696465642e000000000000")
+	if reason := DecodeRevertReason(data); reason != "Not enough Ether provided." {
+		t.Errorf("wrong Error(string) reason %q", reason)
+	}
+
+	panicData := append(hexutil.MustDecode("0x4e487b71"), common.LeftPadBytes([]byte{0x11}, 32)...)
+	if reason := DecodeRevertReason(panicData); reason != "panic 0x11: arithmetic overflow or underflow" {
+		t.Errorf("wrong Panic(uint256) reason %q", reason)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(`[{"inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := parsed.Errors["InsufficientBalance"]
+	args, err := e.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := append(append([]byte{}, e.ID[:4]...), args...)
+	if reason := DecodeRevertReason(custom); reason != "" {
+		t.Errorf("custom error decoded without ABI: %q", reason)
+	}
+	if reason := DecodeRevertReason(custom, &parsed); reason != "InsufficientBalance(available=1, required=2)" {
+		t.Errorf("wrong custom error reason %q", reason)
+	}
+}
+
+func TestWaitMined(t *testing.T) {
+	defer func(interval time.Duration) { WaitPollInterval = interval }(WaitPollInterval)
+	WaitPollInterval = time.Millisecond
+
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx, err := types.SignTx(types.NewTransaction(0, to, big.NewInt(1), 50000, big.NewInt(1), []byte{1}), types.NewEIP155Signer(big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := make([]*types.Header, 20)
+	for n := range headers {
+		headers[n] = &types.Header{Number: big.NewInt(int64(n)), Difficulty: big.NewInt(1)}
+		if n > 0 {
+			headers[n].ParentHash = headers[n-1].Hash()
+		}
+	}
+	// the receipt first points to block 10 of a fork, then to block 12 of the canonical chain
+	receipt := &types.Receipt{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 30000, GasUsed: 30000, Logs: []*types.Log{},
+		TxHash: tx.Hash(), BlockHash: common.Hash{0xde}, BlockNumber: big.NewInt(10)}
+	revert := "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000"
+
+	var lock sync.Mutex
+	head, headCalls := uint64(12), 0
+	var callBlock string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}       `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		lock.Lock()
+		defer lock.Unlock()
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_getTransactionReceipt":
+			rsp["result"] = receipt
+		case "eth_blockNumber":
+			rsp["result"] = hexutil.Uint64(head)
+			head++
+			headCalls++
+		case "eth_getBlockByNumber":
+			var n hexutil.Uint64
+			_ = json.Unmarshal(req.Params[0], &n)
+			rsp["result"] = headers[n]
+			if n == 10 {
+				receipt.BlockNumber, receipt.BlockHash = big.NewInt(12), headers[12].Hash()
+			}
+		case "eth_getTransactionByHash":
+			rsp["result"] = tx
+		case "eth_call":
+			_ = json.Unmarshal(req.Params[1], &callBlock)
+			rsp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted: nope", "data": revert}
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mined, err := WaitMined(context.Background(), client, tx.Hash(), 3)
+	var revertErr *RevertError
+	if !errors.As(err, &revertErr) || revertErr.Reason != "nope" {
+		t.Fatalf("expected the revert reason, got %v", err)
+	}
+	// block 10 is reorged out at head 12, block 12 has 2 confirmations at head 13 and 3 at head 14
+	if mined.BlockNumber.Int64() != 12 || headCalls != 3 {
+		t.Errorf("receipt of block %s returned after %d head checks", mined.BlockNumber, headCalls)
+	}
+	// the replay runs on the state before block 12
+	if callBlock != "0xb" {
+		t.Errorf("replayed at block %s", callBlock)
+	}
+}
@@ -1,7 +1,8 @@
 package ethereum
 
 import (
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/icodeface/chain-kit/mnemonic"
+	"github.com/icodeface/chain-kit/shamir"
 	"github.com/icodeface/hdkeyring"
 )
 
@@ -9,8 +10,39 @@ type Wallet struct {
 	keyring *hdkeyring.Keyring
 }
 
+// NewWallet creates a wallet from a BIP-39 mnemonic in any language, without passphrase.
 func NewWallet(mnemonic string) (*Wallet, error) {
-	keyring, err := hdkeyring.NewFromMnemonic(mnemonic, hdkeyring.KeyTypeECDSA)
+	return NewWalletWithPassphrase(mnemonic, "")
+}
+
+// NewWalletFromShares recovers the mnemonic split by shamir.SplitMnemonic and creates its wallet, without passphrase.
+func NewWalletFromShares(shares []string) (*Wallet, error) {
+	return NewWalletFromSharesWithPassphrase(shares, "")
+}
+
+// NewWalletFromSharesWithPassphrase recovers the mnemonic split by shamir.SplitMnemonic and creates its wallet
+// with the BIP-39 passphrase.
+func NewWalletFromSharesWithPassphrase(shares []string, passphrase string) (*Wallet, error) {
+	words, err := shamir.CombineMnemonic(shares)
+	if err != nil {
+		return nil, err
+	}
+	return NewWalletWithPassphrase(words, passphrase)
+}
+
+// NewWalletWithPassphrase creates a wallet from a BIP-39 mnemonic and passphrase.
+func NewWalletWithPassphrase(words string, passphrase string) (*Wallet, error) {
+	seed, err := mnemonic.NewSeed(words, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewWalletFromSeed(seed)
+}
+
+// NewWalletFromSeed creates a wallet from a 64 bytes BIP-39 seed. The seed is not used after it returns
+// and may be zeroized by the caller.
+func NewWalletFromSeed(seed []byte) (*Wallet, error) {
+	keyring, err := hdkeyring.NewKeyring(seed, hdkeyring.KeyTypeECDSA)
 	if err != nil {
 		return nil, err
 	}
@@ -22,9 +54,5 @@ func (w *Wallet) DeriveAccount(path hdkeyring.DerivationPath) (*Account, error)
 	if err != nil {
 		return nil, err
 	}
-	address := crypto.PubkeyToAddress(pk.PublicKey)
-	return &Account{
-		PrivateKey: pk,
-		Address:    address,
-	}, nil
+	return AccountFromPrivateKey(pk), nil
 }
@@ -1,6 +1,9 @@
 package ethereum
 
-import "testing"
+import (
+	"github.com/icodeface/chain-kit/shamir"
+	"testing"
+)
 
 func TestWallet(t *testing.T) {
 	mnemonic := "tag volcano eight thank tide danger coast health above argue embrace heavy"
@@ -26,3 +29,57 @@ func TestWallet(t *testing.T) {
 	}
 
 }
+
+func TestWalletWithPassphrase(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	for passphrase, expected := range map[string]string{
+		"":       "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", // MetaMask
+		"TREZOR": "0x9c32F71D4DB8Fb9e1A58B0a80dF79935e7256FA6",
+	} {
+		wallet, err := NewWalletWithPassphrase(mnemonic, passphrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account, err := wallet.DeriveAccount(DerivePath(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.Address.Hex() != expected {
+			t.Errorf("wrong address %s with passphrase %q", account.Address.Hex(), passphrase)
+		}
+	}
+	if _, err := NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err == nil {
+		t.Error("expected checksum error")
+	}
+}
+
+func TestWalletFromShares(t *testing.T) {
+	groups, err := shamir.SplitMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 1, []shamir.Group{{Threshold: 2, Count: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := NewWalletFromShares([]string{groups[0][2], groups[0][0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := wallet.DeriveAccount(DerivePath(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address.Hex() != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("wrong address %s", account.Address.Hex())
+	}
+
+	wallet, err = NewWalletFromSharesWithPassphrase([]string{groups[0][1], groups[0][2]}, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := NewWalletWithPassphrase("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _ = wallet.DeriveAccount(DerivePath(0, 0))
+	if expectedAccount, _ := expected.DeriveAccount(DerivePath(0, 0)); account.Address != expectedAccount.Address {
+		t.Errorf("passphrase ignored: %s", account.Address.Hex())
+	}
+}
@@ -0,0 +1,67 @@
+package ethereum
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/icodeface/hdkeyring"
+	"github.com/icodeface/hdkeyring/bip32"
+	"golang.org/x/xerrors"
+)
+
+// AccountPath returns the account level derivation path m/44'/60'/account'.
+func AccountPath(account int64) hdkeyring.DerivationPath {
+	return hdkeyring.MustParseDerivationPath(fmt.Sprintf("m/44'/60'/%d'", account))
+}
+
+// ExtendedPublicKey exports the xpub of the account, for a WatchWallet.
+func (w *Wallet) ExtendedPublicKey(account int64) (string, error) {
+	key, err := w.keyring.DeriveKey(AccountPath(account))
+	if err != nil {
+		return "", err
+	}
+	return key.PublicKey().B58Serialize(), nil
+}
+
+// WatchWallet derives the addresses of an account from its xpub, without any private key.
+type WatchWallet struct {
+	key *bip32.Key
+}
+
+// NewWatchWallet creates a watch-only wallet from the account level xpub exported by Wallet.ExtendedPublicKey.
+func NewWatchWallet(xpub string) (*WatchWallet, error) {
+	key, err := bip32.B58Deserialize(xpub)
+	if err != nil {
+		return nil, xerrors.Errorf("decode xpub: %w", err)
+	}
+	if key.IsPrivate || !bytes.Equal(key.Version, bip32.PublicWalletVersion) {
+		return nil, xerrors.New("not an extended public key")
+	}
+	if key.Depth != 3 {
+		return nil, xerrors.Errorf("expected an account level xpub (depth 3), got depth %d", key.Depth)
+	}
+	return &WatchWallet{key: key}, nil
+}
+
+// DerivePublicKey derives the public key of the non-hardened path change/index below the account.
+func (w *WatchWallet) DerivePublicKey(change uint32, index uint32) (*ecdsa.PublicKey, error) {
+	key, err := w.key.NewChildKey(change)
+	if err != nil {
+		return nil, err
+	}
+	if key, err = key.NewChildKey(index); err != nil {
+		return nil, err
+	}
+	return crypto.DecompressPubkey(key.Key)
+}
+
+// DeriveAddress derives the address 0/index below the account, the same as Wallet.DeriveAccount(DerivePath(account, index)).
+func (w *WatchWallet) DeriveAddress(index uint32) (common.Address, error) {
+	pub, err := w.DerivePublicKey(0, index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,52 @@
+package ethereum
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWatchWallet(t *testing.T) {
+	wallet, err := NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := wallet.ExtendedPublicKey(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(xpub, "xpub") {
+		t.Fatalf("wrong xpub %s", xpub)
+	}
+	watch, err := NewWatchWallet(xpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 5; i++ {
+		account, err := wallet.DeriveAccount(DerivePath(0, int64(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		address, err := watch.DeriveAddress(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address != account.Address {
+			t.Errorf("index %d: watch address %s, wallet address %s", i, address.Hex(), account.Address.Hex())
+		}
+	}
+
+	key, err := wallet.keyring.DeriveKey(AccountPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWatchWallet(key.B58Serialize()); err == nil {
+		t.Error("xprv accepted as xpub")
+	}
+	root, err := wallet.keyring.DeriveKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWatchWallet(root.PublicKey().B58Serialize()); err == nil {
+		t.Error("master xpub accepted as account xpub")
+	}
+}
@@ -7,10 +7,10 @@ import (
 	"fmt"
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/crypto"
-	"github.com/icodeface/chain-kit/filecoin/sigs"
 	"github.com/icodeface/chain-kit/filecoin/types"
 	"github.com/icodeface/hdkeyring"
 	"github.com/ipfs/go-cid"
+	"github.com/minio/blake2b-simd"
 	"golang.org/x/xerrors"
 	"math/big"
 	"time"
@@ -19,22 +19,47 @@ import (
 type Account struct {
 	PrivateKey *ecdsa.PrivateKey
 	Address    address.Address
+
+	// Signer, if set, signs for the account instead of PrivateKey, which may then be nil.
+	Signer Signer
+}
+
+// AccountFromPrivateKey creates an account with the secp256k1 (f1) address of the private key.
+func AccountFromPrivateKey(pk *ecdsa.PrivateKey) (*Account, error) {
+	addr, err := address.NewSecp256k1Address(hdkeyring.ECDSAPublicKeyBytes(&pk.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		PrivateKey: pk,
+		Address:    addr,
+	}, nil
 }
 
 func (account *Account) SignMessage(msg *types.Message) (*types.SignedMessage, error) {
+	return account.SignMessageContext(context.Background(), msg)
+}
+
+// SignMessageContext is SignMessage with a context for the Signer of the account.
+func (account *Account) SignMessageContext(ctx context.Context, msg *types.Message) (*types.SignedMessage, error) {
 	mb, err := msg.ToStorageBlock()
 	if err != nil {
 		return nil, xerrors.Errorf("serializing message: %w", err)
 	}
 
-	sig, err := sigs.Sign(crypto.SigTypeSecp256k1, hdkeyring.ECDSAPrivateKeyBytes(account.PrivateKey), mb.Cid().Bytes())
+	// secp256k1 signatures are over the blake2b-256 hash of the message cid
+	digest := blake2b.Sum256(mb.Cid().Bytes())
+	sig, err := account.signer().SignDigest(ctx, digest[:])
 	if err != nil {
 		return nil, fmt.Errorf("sign message: %w", err)
 	}
 
 	return &types.SignedMessage{
-		Message:   msg,
-		Signature: sig,
+		Message: msg,
+		Signature: &crypto.Signature{
+			Type: crypto.SigTypeSecp256k1,
+			Data: sig,
+		},
 	}, nil
 }
 
@@ -86,7 +111,9 @@ func (account *Account) Transfer(client *Client, to string, amount *big.Int) (ci
 	}
 	msg.Nonce = nonce
 
-	signed, err := account.SignMessage(msg)
+	timeout, cancel = context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	signed, err := account.SignMessageContext(timeout, msg)
 	if err != nil {
 		return cid.Undef, err
 	}
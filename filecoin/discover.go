@@ -0,0 +1,72 @@
+package filecoin
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/icodeface/chain-kit/discovery"
+	"github.com/icodeface/hdkeyring"
+	"strings"
+)
+
+// PathScheme is a derivation path layout used by wallets.
+type PathScheme = discovery.PathScheme
+
+// DiscoveredAccount is a used address found by Discovery, with the address of the current network.
+type DiscoveredAccount = discovery.Account
+
+var (
+	// SchemeBIP44 is used by Glif, the Filecoin Ledger app and DerivePath(0, i).
+	SchemeBIP44 = PathScheme{Name: "bip44", Format: "m/44'/461'/0'/0/%d"}
+	// SchemeAccounts creates one account per hardened index.
+	SchemeAccounts = PathScheme{Name: "accounts", Format: "m/44'/461'/%d'/0/0"}
+	// SchemeTestnet is the testnet coin type used by Glif and Ledger.
+	SchemeTestnet = PathScheme{Name: "testnet", Format: "m/44'/1'/0'/0/%d"}
+)
+
+// Discovery finds the used addresses of a wallet. For each scheme it walks the indexes until GapLimit
+// consecutive addresses have no actor on chain.
+type Discovery struct {
+	GapLimit int
+	Schemes  []PathScheme
+
+	client *Client
+}
+
+func NewDiscovery(client *Client) *Discovery {
+	return &Discovery{
+		GapLimit: discovery.DefaultGapLimit,
+		Schemes:  []PathScheme{SchemeBIP44, SchemeAccounts},
+		client:   client,
+	}
+}
+
+// Discover returns the used addresses of the wallet, by scheme and index.
+func (d *Discovery) Discover(ctx context.Context, wallet *Wallet) ([]*DiscoveredAccount, error) {
+	return discovery.Walk(ctx, d.Schemes, d.GapLimit, func(ctx context.Context, path hdkeyring.DerivationPath) (string, bool, error) {
+		account, err := wallet.DeriveAccount(path)
+		if err != nil {
+			return "", false, err
+		}
+		used, err := d.used(ctx, account.Address)
+		return account.Address.String(), used, err
+	})
+}
+
+// used reports whether the address has an actor, which is created by the first transfer to it.
+func (d *Discovery) used(ctx context.Context, addr address.Address) (bool, error) {
+	actor, err := d.client.StateGetActor(ctx, addr, nil)
+	if isActorNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return actor != nil, nil
+}
+
+// isActorNotFound reports whether err is the error of StateGetActor for an address without actor. Lotus sends it
+// as a plain JSON-RPC error without a dedicated code, e.g. "resolution lookup failed: actor not found", and
+// Client.Request only keeps its text, so the message is the only way to tell it from a node failure.
+func isActorNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "actor not found")
+}
@@ -0,0 +1,46 @@
+package filecoin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filecoin-project/go-address"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscovery_Used(t *testing.T) {
+	used, _ := address.NewIDAddress(1000)
+	unused, _ := address.NewIDAddress(1001)
+	failing, _ := address.NewIDAddress(1002)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		rsp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Params[0].(string) {
+		case used.String():
+			rsp["result"] = map[string]interface{}{"Nonce": 0, "Balance": "1000"}
+		case unused.String():
+			rsp["error"] = map[string]interface{}{"code": 1, "message": "resolution lookup failed: actor not found"}
+		default:
+			rsp["error"] = map[string]interface{}{"code": 1, "message": "loading tipset: blockstore: block not found"}
+		}
+		_ = json.NewEncoder(w).Encode(rsp)
+	}))
+	defer server.Close()
+
+	discovery := NewDiscovery(NewClient(server.URL, ""))
+	for addr, expected := range map[address.Address]bool{used: true, unused: false} {
+		if isUsed, err := discovery.used(context.Background(), addr); err != nil || isUsed != expected {
+			t.Errorf("%s: expected used %v, got %v %v", addr, expected, isUsed, err)
+		}
+	}
+	// a node failure is not an unused address
+	if _, err := discovery.used(context.Background(), failing); err == nil {
+		t.Error("expected the node error")
+	}
+}
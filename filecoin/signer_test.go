@@ -0,0 +1,75 @@
+package filecoin
+
+import (
+	"context"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/icodeface/chain-kit/filecoin/sigs"
+	"github.com/icodeface/chain-kit/filecoin/types"
+	"github.com/icodeface/chain-kit/remotesigner"
+	"github.com/icodeface/hdkeyring"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	wallet, err := NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := wallet.DeriveAccount(DerivePath(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := remotesigner.NewServer([]byte("secret"))
+	server.AddSecp256k1Key("hot", account.PrivateKey)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	signer, err := NewRemoteSigner(context.Background(), remotesigner.NewClient(ts.URL, []byte("secret")), "hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := AccountFromSigner(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Address != account.Address {
+		t.Fatalf("wrong remote address %s", remote.Address)
+	}
+
+	msg := &types.Message{
+		To:         account.Address,
+		From:       account.Address,
+		Nonce:      1,
+		Value:      abi.NewTokenAmount(1000),
+		GasLimit:   1000000,
+		GasFeeCap:  abi.NewTokenAmount(100),
+		GasPremium: abi.NewTokenAmount(10),
+	}
+	signed, err := remote.SignMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb, err := msg.ToStorageBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sigs.Verify(signed.Signature, account.Address, mb.Cid().Bytes()); err != nil {
+		t.Errorf("invalid remote signature: %v", err)
+	}
+	// the same signature as the filecoin secp256k1 signer
+	expected, err := sigs.Sign(signed.Signature.Type, hdkeyring.ECDSAPrivateKeyBytes(account.PrivateKey), mb.Cid().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(expected.Data) != string(signed.Signature.Data) {
+		t.Errorf("remote signature %x, local %x", signed.Signature.Data, expected.Data)
+	}
+
+	// the context of the caller reaches the remote signer
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := remote.SignMessageContext(cancelled, msg); err == nil {
+		t.Error("expected a cancelled context error")
+	}
+}
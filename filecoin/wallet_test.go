@@ -1,9 +1,65 @@
 package filecoin
 
 import (
+	"github.com/filecoin-project/go-address"
+	"github.com/icodeface/chain-kit/shamir"
 	"testing"
 )
 
 func TestWallet(t *testing.T) {
 
 }
+
+func TestWalletWithPassphrase(t *testing.T) {
+	defer func(network address.Network) { address.CurrentNetwork = network }(address.CurrentNetwork)
+	address.CurrentNetwork = address.Mainnet
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	for passphrase, expected := range map[string]string{
+		"":       "f1qode47ievxlxzk6z2viuovedabmn3tq6t57uqhq",
+		"TREZOR": "f1m7huxiig63m7gfa4tsj3oyepriqipjs7rlvezmq",
+	} {
+		wallet, err := NewWalletWithPassphrase(mnemonic, passphrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account, err := wallet.DeriveAccount(DerivePath(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.Address.String() != expected {
+			t.Errorf("wrong address %s with passphrase %q", account.Address, passphrase)
+		}
+	}
+}
+
+func TestWalletFromShares(t *testing.T) {
+	defer func(network address.Network) { address.CurrentNetwork = network }(address.CurrentNetwork)
+	address.CurrentNetwork = address.Mainnet
+	groups, err := shamir.SplitMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 1, []shamir.Group{{Threshold: 2, Count: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := NewWalletFromShares([]string{groups[0][2], groups[0][0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := wallet.DeriveAccount(DerivePath(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address.String() != "f1qode47ievxlxzk6z2viuovedabmn3tq6t57uqhq" {
+		t.Errorf("wrong address %s", account.Address)
+	}
+
+	wallet, err = NewWalletFromSharesWithPassphrase([]string{groups[0][1], groups[0][2]}, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err = wallet.DeriveAccount(DerivePath(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address.String() != "f1m7huxiig63m7gfa4tsj3oyepriqipjs7rlvezmq" {
+		t.Errorf("wrong address %s with passphrase", account.Address)
+	}
+}
@@ -0,0 +1,61 @@
+package filecoin
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/filecoin-project/go-address"
+	"github.com/icodeface/hdkeyring"
+	"github.com/icodeface/hdkeyring/bip32"
+	"golang.org/x/xerrors"
+)
+
+// AccountPath returns the account level derivation path m/44'/461'/account'.
+func AccountPath(account int64) hdkeyring.DerivationPath {
+	return hdkeyring.MustParseDerivationPath(fmt.Sprintf("m/44'/461'/%d'", account))
+}
+
+// ExtendedPublicKey exports the xpub of the account, for a WatchWallet.
+func (w *Wallet) ExtendedPublicKey(account int64) (string, error) {
+	key, err := w.keyring.DeriveKey(AccountPath(account))
+	if err != nil {
+		return "", err
+	}
+	return key.PublicKey().B58Serialize(), nil
+}
+
+// WatchWallet derives the secp256k1 addresses of an account from its xpub, without any private key.
+type WatchWallet struct {
+	key *bip32.Key
+}
+
+// NewWatchWallet creates a watch-only wallet from the account level xpub exported by Wallet.ExtendedPublicKey.
+func NewWatchWallet(xpub string) (*WatchWallet, error) {
+	key, err := bip32.B58Deserialize(xpub)
+	if err != nil {
+		return nil, xerrors.Errorf("decode xpub: %w", err)
+	}
+	if key.IsPrivate || !bytes.Equal(key.Version, bip32.PublicWalletVersion) {
+		return nil, xerrors.New("not an extended public key")
+	}
+	if key.Depth != 3 {
+		return nil, xerrors.Errorf("expected an account level xpub (depth 3), got depth %d", key.Depth)
+	}
+	return &WatchWallet{key: key}, nil
+}
+
+// DeriveAddress derives the address 0/index below the account, the same as Wallet.DeriveAccount(DerivePath(account, index)).
+func (w *WatchWallet) DeriveAddress(index uint32) (address.Address, error) {
+	key, err := w.key.NewChildKey(0)
+	if err != nil {
+		return address.Undef, err
+	}
+	if key, err = key.NewChildKey(index); err != nil {
+		return address.Undef, err
+	}
+	pub, err := crypto.DecompressPubkey(key.Key)
+	if err != nil {
+		return address.Undef, err
+	}
+	return address.NewSecp256k1Address(hdkeyring.ECDSAPublicKeyBytes(pub))
+}
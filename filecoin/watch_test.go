@@ -0,0 +1,33 @@
+package filecoin
+
+import (
+	"testing"
+)
+
+func TestWatchWallet(t *testing.T) {
+	wallet, err := NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := wallet.ExtendedPublicKey(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watch, err := NewWatchWallet(xpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 5; i++ {
+		account, err := wallet.DeriveAccount(DerivePath(0, int64(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := watch.DeriveAddress(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != account.Address {
+			t.Errorf("index %d: watch address %s, wallet address %s", i, addr, account.Address)
+		}
+	}
+}
@@ -8,7 +8,9 @@ require (
 	github.com/ethereum/go-ethereum v1.10.13
 	github.com/filecoin-project/go-address v0.0.4
 	github.com/filecoin-project/go-state-types v0.0.0-20201013222834-41ea465f274f
+	github.com/gagliardetto/binary v0.6.1
 	github.com/gagliardetto/solana-go v1.4.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/icodeface/hdkeyring v1.1.1
 	github.com/ipfs/go-block-format v0.0.2
 	github.com/ipfs/go-cid v0.0.7
@@ -16,8 +18,10 @@ require (
 	github.com/minio/blake2b-simd v0.0.0-20160723061019-3f5f724cb5b1
 	github.com/shopspring/decimal v1.3.1
 	github.com/supranational/blst v0.2.0
+	github.com/tyler-smith/go-bip39 v1.1.0
 	github.com/whyrusleeping/cbor-gen v0.0.0-20200812213548-958ddffe352c
-	golang.org/x/crypto v0.0.0-20210813211128-0a44fdfbc16e // indirect
+	golang.org/x/crypto v0.0.0-20210813211128-0a44fdfbc16e
+	golang.org/x/text v0.3.6
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,387 @@
+// Package keystore stores mnemonics and private keys of all chains encrypted at rest, in a single file.
+//
+// Every entry is encrypted on its own with a key derived from its passphrase by argon2id or scrypt,
+// with AES-256-GCM or XChaCha20-Poly1305. The label and type of the entry are authenticated,
+// so entries can't be swapped in the file. The metadata and creation time are stored in clear and not
+// authenticated: anyone able to write the file can change them.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SecretType is the kind of secret held by an entry.
+type SecretType string
+
+const (
+	TypeMnemonic        SecretType = "mnemonic"
+	TypeEthereumKey     SecretType = "eth-secp256k1"
+	TypeFilecoinSecpKey SecretType = "fil-secp256k1"
+	TypeFilecoinBLSKey  SecretType = "fil-bls"
+	TypeSolanaKey       SecretType = "sol-ed25519"
+)
+
+// KDF is the passphrase key derivation function.
+type KDF string
+
+const (
+	KDFArgon2id KDF = "argon2id"
+	KDFScrypt   KDF = "scrypt"
+)
+
+// Cipher is the authenticated encryption of the secrets.
+type Cipher string
+
+const (
+	CipherAESGCM            Cipher = "aes-256-gcm"
+	CipherXChaCha20Poly1305 Cipher = "xchacha20-poly1305"
+)
+
+var (
+	ErrNotFound          = errors.New("keystore entry not found")
+	ErrExists            = errors.New("keystore entry already exists")
+	ErrWrongPassphrase   = errors.New("wrong passphrase or corrupted entry")
+	ErrWrongSecretType   = errors.New("keystore entry has another secret type")
+	ErrEmptyPassphrase   = errors.New("empty passphrase")
+	errUnsupportedKDF    = errors.New("unsupported kdf")
+	errUnsupportedCipher = errors.New("unsupported cipher")
+)
+
+// KDFParams holds the parameters of the key derivation. Argon2id uses Time, Memory (KiB) and Threads,
+// scrypt uses N, R and P.
+type KDFParams struct {
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+}
+
+type encrypted struct {
+	KDF        KDF       `json:"kdf"`
+	KDFParams  KDFParams `json:"kdfparams"`
+	Cipher     Cipher    `json:"cipher"`
+	Nonce      []byte    `json:"nonce"`
+	CipherText []byte    `json:"ciphertext"`
+}
+
+type entry struct {
+	Label     string            `json:"label"`
+	Type      SecretType        `json:"type"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Crypto    encrypted         `json:"crypto"`
+}
+
+// Info describes an entry without decrypting it.
+type Info struct {
+	Label     string
+	Type      SecretType
+	Metadata  map[string]string
+	CreatedAt time.Time
+}
+
+type file struct {
+	Version int      `json:"version"`
+	Entries []*entry `json:"entries"`
+}
+
+// Keystore is an encrypted store backed by a JSON file. It is safe for concurrent use.
+type Keystore struct {
+	path string
+
+	// KDF and Cipher are used for new entries and passphrase changes.
+	KDF    KDF
+	Cipher Cipher
+
+	lock    sync.Mutex
+	entries map[string]*entry
+}
+
+// Open loads the keystore file at path. A missing file opens an empty keystore, created on the first write.
+func Open(path string) (*Keystore, error) {
+	ks := &Keystore{
+		path:    path,
+		KDF:     KDFArgon2id,
+		Cipher:  CipherXChaCha20Poly1305,
+		entries: map[string]*entry{},
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, xerrors.Errorf("decode keystore %s: %w", path, err)
+	}
+	for _, e := range f.Entries {
+		ks.entries[e.Label] = e
+	}
+	return ks, nil
+}
+
+// Labels returns the labels of all entries, sorted.
+func (ks *Keystore) Labels() []string {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	return sortedLabels(ks.entries)
+}
+
+// Info returns the description of the entry.
+func (ks *Keystore) Info(label string) (*Info, error) {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	e, ok := ks.entries[label]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &Info{Label: e.Label, Type: e.Type, Metadata: copyMetadata(e.Metadata), CreatedAt: e.CreatedAt}, nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+	return c
+}
+
+// Put encrypts secret with passphrase and stores it under label. Existing labels are not overwritten.
+// The caller keeps ownership of secret and may zeroize it afterwards.
+func (ks *Keystore) Put(label string, secretType SecretType, secret []byte, passphrase []byte, metadata map[string]string) error {
+	if len(passphrase) == 0 {
+		return ErrEmptyPassphrase
+	}
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	if _, ok := ks.entries[label]; ok {
+		return ErrExists
+	}
+	e := &entry{Label: label, Type: secretType, Metadata: copyMetadata(metadata), CreatedAt: time.Now().UTC()}
+	if err := ks.encrypt(e, secret, passphrase); err != nil {
+		return err
+	}
+	ks.entries[label] = e
+	if err := ks.save(); err != nil {
+		delete(ks.entries, label)
+		return err
+	}
+	return nil
+}
+
+// Delete removes the entry.
+func (ks *Keystore) Delete(label string) error {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	e, ok := ks.entries[label]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(ks.entries, label)
+	if err := ks.save(); err != nil {
+		ks.entries[label] = e
+		return err
+	}
+	return nil
+}
+
+// Decrypt returns the secret of the entry. Zeroize it once it is no longer needed.
+func (ks *Keystore) Decrypt(label string, passphrase []byte) (*Secret, error) {
+	ks.lock.Lock()
+	e, ok := ks.entries[label]
+	ks.lock.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	data, err := decrypt(e, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{Type: e.Type, data: data}, nil
+}
+
+// ChangePassphrase encrypts the entry again under newPassphrase, with a new salt and nonce.
+func (ks *Keystore) ChangePassphrase(label string, oldPassphrase []byte, newPassphrase []byte) error {
+	if len(newPassphrase) == 0 {
+		return ErrEmptyPassphrase
+	}
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	e, ok := ks.entries[label]
+	if !ok {
+		return ErrNotFound
+	}
+	data, err := decrypt(e, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	defer Zeroize(data)
+
+	updated := *e
+	if err := ks.encrypt(&updated, data, newPassphrase); err != nil {
+		return err
+	}
+	ks.entries[label] = &updated
+	if err := ks.save(); err != nil {
+		ks.entries[label] = e
+		return err
+	}
+	return nil
+}
+
+func (ks *Keystore) save() error {
+	f := file{Version: 1}
+	for _, label := range sortedLabels(ks.entries) {
+		f.Entries = append(f.Entries, ks.entries[label])
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write and rename, so a crash never leaves a truncated keystore
+	tmp := ks.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
+
+func sortedLabels(entries map[string]*entry) []string {
+	labels := make([]string, 0, len(entries))
+	for label := range entries {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+func (ks *Keystore) encrypt(e *entry, secret []byte, passphrase []byte) error {
+	params := KDFParams{Salt: make([]byte, 16)}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return err
+	}
+	switch ks.KDF {
+	case KDFArgon2id:
+		params.Time, params.Memory, params.Threads = 3, 64*1024, 4
+	case KDFScrypt:
+		params.N, params.R, params.P = 1<<17, 8, 1
+	default:
+		return errUnsupportedKDF
+	}
+	key, err := deriveKey(ks.KDF, params, passphrase)
+	if err != nil {
+		return err
+	}
+	defer Zeroize(key)
+
+	aead, err := newAEAD(ks.Cipher, key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	e.Crypto = encrypted{
+		KDF:        ks.KDF,
+		KDFParams:  params,
+		Cipher:     ks.Cipher,
+		Nonce:      nonce,
+		CipherText: aead.Seal(nil, nonce, secret, additionalData(e)),
+	}
+	return nil
+}
+
+func decrypt(e *entry, passphrase []byte) ([]byte, error) {
+	key, err := deriveKey(e.Crypto.KDF, e.Crypto.KDFParams, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(key)
+
+	aead, err := newAEAD(e.Crypto.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Crypto.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	data, err := aead.Open(nil, e.Crypto.Nonce, e.Crypto.CipherText, additionalData(e))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return data, nil
+}
+
+// additionalData binds the ciphertext to the entry label and type.
+func additionalData(e *entry) []byte {
+	return []byte(string(e.Type) + "\x00" + e.Label)
+}
+
+func deriveKey(kdf KDF, params KDFParams, passphrase []byte) ([]byte, error) {
+	switch kdf {
+	case KDFArgon2id:
+		if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+			return nil, xerrors.New("invalid argon2id parameters")
+		}
+		return argon2.IDKey(passphrase, params.Salt, params.Time, params.Memory, params.Threads, 32), nil
+	case KDFScrypt:
+		return scrypt.Key(passphrase, params.Salt, params.N, params.R, params.P, 32)
+	}
+	return nil, errUnsupportedKDF
+}
+
+func newAEAD(c Cipher, key []byte) (cipher.AEAD, error) {
+	switch c {
+	case CipherAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case CipherXChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	}
+	return nil, errUnsupportedCipher
+}
+
+// Secret is a decrypted secret.
+type Secret struct {
+	Type SecretType
+	data []byte
+}
+
+// Bytes returns the secret. The slice is wiped by Zeroize.
+func (s *Secret) Bytes() []byte {
+	return s.data
+}
+
+// Zeroize overwrites the secret in memory.
+func (s *Secret) Zeroize() {
+	Zeroize(s.data)
+}
+
+// Zeroize overwrites b with zeros.
+func Zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
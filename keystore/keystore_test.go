@@ -0,0 +1,156 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"github.com/icodeface/chain-kit/ethereum"
+	"github.com/icodeface/chain-kit/solana"
+	"path/filepath"
+	"testing"
+)
+
+const testMnemonic = "tag volcano eight thank tide danger coast health above argue embrace heavy"
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keystore.json")
+	ks, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	for _, kdf := range []KDF{KDFArgon2id, KDFScrypt} {
+		for _, c := range []Cipher{CipherAESGCM, CipherXChaCha20Poly1305} {
+			ks.KDF, ks.Cipher = kdf, c
+			if err := ks.Put(string(kdf)+"/"+string(c), TypeEthereumKey, secret, []byte("pass"), map[string]string{"chain": "eth"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// the keystore keeps its own copy of the metadata
+	metadata := map[string]string{"chain": "eth"}
+	if err := ks.Put("metadata", TypeEthereumKey, secret, []byte("pass"), metadata); err != nil {
+		t.Fatal(err)
+	}
+	metadata["chain"] = "sol"
+	if info, _ := ks.Info("metadata"); info.Metadata["chain"] != "eth" {
+		t.Errorf("metadata changed by the caller: %v", info.Metadata)
+	}
+	if err := ks.Delete("metadata"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Put("argon2id/aes-256-gcm", TypeEthereumKey, secret, []byte("pass"), nil); err != ErrExists {
+		t.Errorf("expected ErrExists, got %v", err)
+	}
+
+	reopened, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reopened.Labels()) != 4 {
+		t.Fatalf("expected 4 entries, got %v", reopened.Labels())
+	}
+	for _, label := range reopened.Labels() {
+		s, err := reopened.Decrypt(label, []byte("pass"))
+		if err != nil {
+			t.Fatalf("%s: %v", label, err)
+		}
+		if !bytes.Equal(s.Bytes(), secret) {
+			t.Errorf("%s: wrong secret", label)
+		}
+		s.Zeroize()
+		if !bytes.Equal(s.Bytes(), make([]byte, len(secret))) {
+			t.Errorf("%s: secret not zeroized", label)
+		}
+		if _, err := reopened.Decrypt(label, []byte("wrong")); err != ErrWrongPassphrase {
+			t.Errorf("%s: expected ErrWrongPassphrase, got %v", label, err)
+		}
+	}
+	info, err := reopened.Info("scrypt/aes-256-gcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Type != TypeEthereumKey || info.Metadata["chain"] != "eth" {
+		t.Errorf("wrong info %+v", info)
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	ks, err := Open(filepath.Join(t.TempDir(), "keystore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.PutMnemonic("main", testMnemonic, []byte("old"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.ChangePassphrase("main", []byte("wrong"), []byte("new")); err != ErrWrongPassphrase {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+	if err := ks.ChangePassphrase("main", []byte("old"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Decrypt("main", []byte("old")); err != ErrWrongPassphrase {
+		t.Errorf("old passphrase still works: %v", err)
+	}
+	if _, err := ks.OpenSolanaAccount("main", []byte("new")); err != ErrWrongSecretType {
+		t.Errorf("expected ErrWrongSecretType, got %v", err)
+	}
+
+	wallet, err := ks.OpenEthereumWallet("main", []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := wallet.DeriveAccount(ethereum.DerivePath(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address.Hex() != "0xC49926C4124cEe1cbA0Ea94Ea31a6c12318df947" {
+		t.Errorf("wrong address %s", account.Address.Hex())
+	}
+
+	if err := ks.PutEthereumAccount("eth", account, []byte("pass"), nil); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := ks.OpenEthereumAccount("eth", []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.Address != account.Address {
+		t.Errorf("wrong account %s", opened.Address.Hex())
+	}
+	signerOnly := ethereum.AccountFromSigner(ethereum.NewKeySigner(account.PrivateKey))
+	if err := ks.PutEthereumAccount("signer", signerOnly, []byte("pass"), nil); err != ethereum.ErrNoPrivateKey {
+		t.Errorf("expected ErrNoPrivateKey, got %v", err)
+	}
+}
+
+func TestSolanaKeyPair(t *testing.T) {
+	ks, err := Open(filepath.Join(t.TempDir(), "keystore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	forged := append(append([]byte{}, key[:ed25519.SeedSize]...), other[ed25519.SeedSize:]...)
+
+	if err := ks.PutSolanaAccount("forged", solana.AccountFromPrivateKey(forged), []byte("pass"), nil); err == nil {
+		t.Error("expected a public key mismatch error")
+	}
+	if err := ks.Put("forged", TypeSolanaKey, forged, []byte("pass"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.OpenSolanaAccount("forged", []byte("pass")); err == nil {
+		t.Error("expected a public key mismatch error")
+	}
+
+	account := solana.AccountFromPrivateKey(key)
+	if err := ks.PutSolanaAccount("sol", account, []byte("pass"), nil); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := ks.OpenSolanaAccount("sol", []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened.Address != account.Address {
+		t.Errorf("wrong account %s", opened.Address)
+	}
+}
@@ -0,0 +1,145 @@
+package keystore
+
+import (
+	"crypto/ed25519"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/icodeface/chain-kit/ethereum"
+	"github.com/icodeface/chain-kit/filecoin"
+	"github.com/icodeface/chain-kit/mnemonic"
+	"github.com/icodeface/chain-kit/solana"
+	"golang.org/x/xerrors"
+)
+
+// PutMnemonic validates and stores a BIP-39 mnemonic, in any language.
+func (ks *Keystore) PutMnemonic(label string, words string, passphrase []byte, metadata map[string]string) error {
+	if _, err := mnemonic.Validate(words); err != nil {
+		return xerrors.Errorf("invalid mnemonic: %w", err)
+	}
+	return ks.Put(label, TypeMnemonic, []byte(words), passphrase, metadata)
+}
+
+// PutEthereumAccount stores the private key of the account.
+func (ks *Keystore) PutEthereumAccount(label string, account *ethereum.Account, passphrase []byte, metadata map[string]string) error {
+	if account.PrivateKey == nil {
+		return ethereum.ErrNoPrivateKey
+	}
+	key := crypto.FromECDSA(account.PrivateKey)
+	defer Zeroize(key)
+	return ks.Put(label, TypeEthereumKey, key, passphrase, metadata)
+}
+
+// PutFilecoinAccount stores the secp256k1 private key of the account.
+func (ks *Keystore) PutFilecoinAccount(label string, account *filecoin.Account, passphrase []byte, metadata map[string]string) error {
+	if account.PrivateKey == nil {
+		return filecoin.ErrNoPrivateKey
+	}
+	key := crypto.FromECDSA(account.PrivateKey)
+	defer Zeroize(key)
+	return ks.Put(label, TypeFilecoinSecpKey, key, passphrase, metadata)
+}
+
+// PutSolanaAccount stores the ed25519 private key of the account.
+func (ks *Keystore) PutSolanaAccount(label string, account *solana.Account, passphrase []byte, metadata map[string]string) error {
+	if account.PrivateKey == nil {
+		return solana.ErrNoPrivateKey
+	}
+	if _, err := solana.AccountFromSecretKey(account.PrivateKey); err != nil {
+		return err
+	}
+	return ks.Put(label, TypeSolanaKey, account.PrivateKey, passphrase, metadata)
+}
+
+func (ks *Keystore) decryptType(label string, passphrase []byte, secretType SecretType) (*Secret, error) {
+	secret, err := ks.Decrypt(label, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Type != secretType {
+		secret.Zeroize()
+		return nil, ErrWrongSecretType
+	}
+	return secret, nil
+}
+
+// seed decrypts the mnemonic and returns its BIP-39 seed without passphrase. The mnemonic never leaves the secret,
+// and the caller zeroizes the seed.
+func (ks *Keystore) seed(label string, passphrase []byte) ([]byte, error) {
+	secret, err := ks.decryptType(label, passphrase, TypeMnemonic)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zeroize()
+	return mnemonic.NewSeedFromBytes(secret.Bytes(), "")
+}
+
+// OpenEthereumWallet decrypts the mnemonic into an ethereum wallet.
+func (ks *Keystore) OpenEthereumWallet(label string, passphrase []byte) (*ethereum.Wallet, error) {
+	seed, err := ks.seed(label, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(seed)
+	return ethereum.NewWalletFromSeed(seed)
+}
+
+// OpenFilecoinWallet decrypts the mnemonic into a filecoin wallet.
+func (ks *Keystore) OpenFilecoinWallet(label string, passphrase []byte) (*filecoin.Wallet, error) {
+	seed, err := ks.seed(label, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(seed)
+	return filecoin.NewWalletFromSeed(seed)
+}
+
+// OpenSolanaWallet decrypts the mnemonic into a solana wallet.
+func (ks *Keystore) OpenSolanaWallet(label string, passphrase []byte) (*solana.Wallet, error) {
+	seed, err := ks.seed(label, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(seed)
+	return solana.NewWalletFromSeed(seed)
+}
+
+// OpenEthereumAccount decrypts an ethereum private key.
+func (ks *Keystore) OpenEthereumAccount(label string, passphrase []byte) (*ethereum.Account, error) {
+	secret, err := ks.decryptType(label, passphrase, TypeEthereumKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zeroize()
+	pk, err := crypto.ToECDSA(secret.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return ethereum.AccountFromPrivateKey(pk), nil
+}
+
+// OpenFilecoinAccount decrypts a filecoin secp256k1 private key. BLS keys are only available through Decrypt.
+func (ks *Keystore) OpenFilecoinAccount(label string, passphrase []byte) (*filecoin.Account, error) {
+	secret, err := ks.decryptType(label, passphrase, TypeFilecoinSecpKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zeroize()
+	pk, err := crypto.ToECDSA(secret.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return filecoin.AccountFromPrivateKey(pk)
+}
+
+// OpenSolanaAccount decrypts a solana private key, stored as a 32 bytes seed or a 64 bytes key.
+func (ks *Keystore) OpenSolanaAccount(label string, passphrase []byte) (*solana.Account, error) {
+	secret, err := ks.decryptType(label, passphrase, TypeSolanaKey)
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zeroize()
+	if len(secret.Bytes()) == ed25519.SeedSize {
+		return solana.AccountFromSeed(secret.Bytes())
+	}
+	// checks that the public key half matches the seed
+	return solana.AccountFromSecretKey(secret.Bytes())
+}
@@ -0,0 +1,193 @@
+// Package mnemonic implements BIP-39 mnemonics in all languages of the specification.
+//
+// Unlike hdkeyring, which only accepts english mnemonics without passphrase, it generates and validates
+// mnemonics in any word list and derives seeds with a passphrase (the "25th word").
+package mnemonic
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+)
+
+// Language is a BIP-39 word list.
+type Language string
+
+const (
+	English            Language = "english"
+	ChineseSimplified  Language = "chinese_simplified"
+	ChineseTraditional Language = "chinese_traditional"
+	Czech              Language = "czech"
+	French             Language = "french"
+	Italian            Language = "italian"
+	Japanese           Language = "japanese"
+	Korean             Language = "korean"
+	Spanish            Language = "spanish"
+)
+
+// Languages lists the supported languages. English comes first, so it wins for mnemonics valid in several lists.
+var Languages = []Language{English, ChineseSimplified, ChineseTraditional, Czech, French, Italian, Japanese, Korean, Spanish}
+
+var (
+	ErrInvalidStrength = errors.New("strength must be a multiple of 32 bits between 128 and 256")
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrInvalidChecksum = errors.New("invalid mnemonic checksum")
+)
+
+type wordList struct {
+	words []string
+	index map[string]int
+}
+
+var wordLists = map[Language]*wordList{}
+
+func init() {
+	for language, words := range map[Language][]string{
+		English:            wordlists.English,
+		ChineseSimplified:  wordlists.ChineseSimplified,
+		ChineseTraditional: wordlists.ChineseTraditional,
+		Czech:              wordlists.Czech,
+		French:             wordlists.French,
+		Italian:            wordlists.Italian,
+		Japanese:           wordlists.Japanese,
+		Korean:             wordlists.Korean,
+		Spanish:            wordlists.Spanish,
+	} {
+		list := &wordList{words: words, index: make(map[string]int, len(words))}
+		for i, word := range words {
+			list.index[norm.NFKD.String(word)] = i
+		}
+		wordLists[language] = list
+	}
+}
+
+// Generate returns a random mnemonic with strength bits of entropy (128 for 12 words up to 256 for 24 words).
+func Generate(strength int, language Language) (string, error) {
+	if strength < 128 || strength > 256 || strength%32 != 0 {
+		return "", ErrInvalidStrength
+	}
+	entropy := make([]byte, strength/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return FromEntropy(entropy, language)
+}
+
+// FromEntropy encodes entropy of 16 to 32 bytes as a mnemonic.
+func FromEntropy(entropy []byte, language Language) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", ErrInvalidStrength
+	}
+	list, ok := wordLists[language]
+	if !ok {
+		return "", xerrors.Errorf("unsupported language %q", language)
+	}
+	checksumBits := bits / 32
+	hash := sha256.Sum256(entropy)
+
+	// entropy followed by the first checksumBits bits of its hash, read 11 bits at a time
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (bits+checksumBits)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = list.words[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	separator := " "
+	if language == Japanese {
+		separator = "　"
+	}
+	return strings.Join(words, separator), nil
+}
+
+// Validate checks the words and the checksum of the mnemonic and returns its language.
+func Validate(mnemonic string) (Language, error) {
+	_, language, err := Entropy(mnemonic)
+	return language, err
+}
+
+// Entropy decodes the mnemonic in the first language whose word list contains all its words.
+func Entropy(mnemonic string) ([]byte, Language, error) {
+	return entropy(bytes.Fields(norm.NFKD.Bytes([]byte(mnemonic))))
+}
+
+func entropy(words [][]byte) ([]byte, Language, error) {
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, "", ErrInvalidMnemonic
+	}
+	result := ErrInvalidMnemonic
+	for _, language := range Languages {
+		entropy, err := decode(words, wordLists[language])
+		if err == nil {
+			return entropy, language, nil
+		}
+		if err == ErrInvalidChecksum {
+			result = err
+		}
+	}
+	return nil, "", result
+}
+
+func decode(words [][]byte, list *wordList) ([]byte, error) {
+	data := new(big.Int)
+	for _, word := range words {
+		i, ok := list.index[string(word)]
+		if !ok {
+			return nil, ErrInvalidMnemonic
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(i)))
+	}
+	checksumBits := len(words) * 11 / 33
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<checksumBits-1))).Int64()
+	data.Rsh(data, uint(checksumBits))
+
+	entropy := make([]byte, checksumBits*4)
+	data.FillBytes(entropy)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, ErrInvalidChecksum
+	}
+	return entropy, nil
+}
+
+// NewSeed validates the mnemonic and returns its 64 bytes BIP-39 seed with the passphrase, which may be empty.
+func NewSeed(mnemonic string, passphrase string) ([]byte, error) {
+	return NewSeedFromBytes([]byte(mnemonic), passphrase)
+}
+
+// NewSeedFromBytes is NewSeed for a mnemonic held in a byte slice, so the caller can zeroize it. The copies made
+// while deriving the seed are zeroized before returning.
+func NewSeedFromBytes(mnemonic []byte, passphrase string) ([]byte, error) {
+	normalized := norm.NFKD.Append(nil, mnemonic...)
+	defer zeroize(normalized)
+	words := bytes.Fields(normalized)
+	entropy, _, err := entropy(words)
+	if err != nil {
+		return nil, err
+	}
+	zeroize(entropy)
+	// words are joined by plain spaces, the seed of a japanese mnemonic doesn't depend on the separator
+	joined := bytes.Join(words, []byte(" "))
+	defer zeroize(joined)
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key(joined, []byte(salt), 2048, 64, sha512.New), nil
+}
+
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
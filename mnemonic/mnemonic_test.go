@@ -0,0 +1,108 @@
+package mnemonic
+
+import (
+	"encoding/hex"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"testing"
+)
+
+// BIP-39 test vectors, the seeds use the passphrase "TREZOR"
+var vectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"8080808080808080808080808080808080808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+		"c0c519bd0e91a2ed54357d9d1ebef6f5af218a153624cf4f2da911a0ed8f7a09e2ef61af0aca007096df430022f7a2b6fb91661a9589097069720d015e4e982f",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		m, err := FromEntropy(entropy, English)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != v.mnemonic {
+			t.Errorf("wrong mnemonic %q", m)
+		}
+		decoded, language, err := Entropy(v.mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if language != English || hex.EncodeToString(decoded) != v.entropy {
+			t.Errorf("wrong entropy %x (%s)", decoded, language)
+		}
+		seed, err := NewSeed(v.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(seed) != v.seed {
+			t.Errorf("wrong seed %x", seed)
+		}
+	}
+}
+
+func TestJapanese(t *testing.T) {
+	entropy := make([]byte, 16)
+	m, err := FromEntropy(entropy, Japanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if norm.NFKD.String(m) != norm.NFKD.String(strings.Repeat("あいこくしん　", 11)+"あおぞら") {
+		t.Errorf("wrong mnemonic %q", m)
+	}
+	if language, err := Validate(m); err != nil || language != Japanese {
+		t.Errorf("japanese mnemonic not detected: %s %v", language, err)
+	}
+	seed, err := NewSeed(m, "㍍ガバヴァぱばぐゞちぢ十人十色")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(seed) != "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55" {
+		t.Errorf("wrong seed %x", seed)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, language := range Languages {
+		for strength := 128; strength <= 256; strength += 32 {
+			m, err := Generate(strength, language)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(strings.Fields(m)) != (strength+strength/32)/11 {
+				t.Errorf("%s: wrong word count for %d bits", language, strength)
+			}
+			if _, err := Validate(m); err != nil {
+				t.Errorf("%s: generated invalid mnemonic: %v", language, err)
+			}
+		}
+	}
+	if _, err := Generate(100, English); err != ErrInvalidStrength {
+		t.Errorf("expected ErrInvalidStrength, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if _, err := Validate("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err != ErrInvalidChecksum {
+		t.Errorf("expected ErrInvalidChecksum, got %v", err)
+	}
+	if _, err := Validate("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon zzz"); err != ErrInvalidMnemonic {
+		t.Errorf("expected ErrInvalidMnemonic, got %v", err)
+	}
+}
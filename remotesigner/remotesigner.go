@@ -0,0 +1,173 @@
+// Package remotesigner implements a simple authenticated HTTP protocol for signing with keys held by another service,
+// and a reference server for it.
+//
+// Requests are JSON POSTs:
+//
+//	/v1/keys/{id}/public  {}                   -> {"type": "secp256k1" | "ed25519", "publicKey": hex}
+//	/v1/keys/{id}/sign    {"data": hex}        -> {"signature": hex}
+//
+// Secp256k1 keys sign a 32-byte digest into a 65-byte [R || S || V] signature with V as 0 or 1,
+// ed25519 keys sign the message itself. Failures answer a non 200 status with {"error": message}.
+//
+// Every request is authenticated with the headers X-Signer-Timestamp (unix seconds), X-Signer-Nonce (a random
+// hex request id) and X-Signer-Signature, the hex HMAC-SHA256 with the shared secret of
+// timestamp + "\n" + nonce + "\n" + path + "\n" + body, path as sent with its id escaped.
+// The server rejects timestamps further than MaxClockSkew from its clock and nonces it already saw.
+//
+// Every response carries X-Signer-Signature too, the HMAC of "response\n" + nonce + "\n" + status + "\n" + body
+// with the nonce of the request, so a client only accepts keys and signatures from a holder of the secret,
+// in answer to its own request.
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/xerrors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyType is the curve of a remote key.
+type KeyType string
+
+const (
+	KeyTypeSecp256k1 KeyType = "secp256k1"
+	KeyTypeEd25519   KeyType = "ed25519"
+)
+
+const (
+	timestampHeader = "X-Signer-Timestamp"
+	nonceHeader     = "X-Signer-Nonce"
+	signatureHeader = "X-Signer-Signature"
+)
+
+// MaxClockSkew is the largest accepted difference between the request timestamp and the server clock.
+var MaxClockSkew = 5 * time.Minute
+
+type publicKeyResponse struct {
+	Type      KeyType `json:"type"`
+	PublicKey string  `json:"publicKey"`
+}
+
+type signRequest struct {
+	Data string `json:"data"`
+}
+
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Client calls a remote signer.
+type Client struct {
+	url    string
+	secret []byte
+
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client of the signer at rawurl, e.g. http://127.0.0.1:8600, authenticated with secret.
+func NewClient(rawurl string, secret []byte) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(rawurl, "/"),
+		secret:     secret,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PublicKey returns the type and public key of the key id. Secp256k1 keys are 65 bytes uncompressed,
+// ed25519 keys 32 bytes.
+func (c *Client) PublicKey(ctx context.Context, id string) (KeyType, []byte, error) {
+	var rsp publicKeyResponse
+	if err := c.call(ctx, "/v1/keys/"+url.PathEscape(id)+"/public", struct{}{}, &rsp); err != nil {
+		return "", nil, err
+	}
+	key, err := hex.DecodeString(rsp.PublicKey)
+	if err != nil {
+		return "", nil, xerrors.Errorf("decode public key: %w", err)
+	}
+	return rsp.Type, key, nil
+}
+
+// Sign signs data with the key id.
+func (c *Client) Sign(ctx context.Context, id string, data []byte) ([]byte, error) {
+	var rsp signResponse
+	if err := c.call(ctx, "/v1/keys/"+url.PathEscape(id)+"/sign", signRequest{Data: hex.EncodeToString(data)}, &rsp); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(rsp.Signature)
+	if err != nil {
+		return nil, xerrors.Errorf("decode signature: %w", err)
+	}
+	return sig, nil
+}
+
+func (c *Client) call(ctx context.Context, path string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(nonceHeader, hex.EncodeToString(nonce))
+	req.Header.Set(signatureHeader, hex.EncodeToString(authenticate(c.secret, timestamp, hex.EncodeToString(nonce), path, body)))
+
+	rsp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(rsp.Header.Get(signatureHeader))
+	authentic := err == nil && hmac.Equal(sig, authenticateResponse(c.secret, hex.EncodeToString(nonce), rsp.StatusCode, data))
+	if rsp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if authentic && json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return xerrors.Errorf("remote signer: %s", e.Error)
+		}
+		return xerrors.Errorf("remote signer: %s", rsp.Status)
+	}
+	if !authentic {
+		return xerrors.New("remote signer: unauthenticated response")
+	}
+	return json.Unmarshal(data, response)
+}
+
+func authenticate(secret []byte, timestamp string, nonce string, path string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s\n", timestamp, nonce, path)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func authenticateResponse(secret []byte, nonce string, status int, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "response\n%s\n%d\n", nonce, status)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
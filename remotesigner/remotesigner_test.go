@@ -0,0 +1,141 @@
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/crypto"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	secpKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer([]byte("secret"))
+	server.AddSecp256k1Key("eth", secpKey)
+	server.AddEd25519Key("sol", edKey)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client := NewClient(ts.URL, []byte("secret"))
+
+	keyType, pub, err := client.PublicKey(ctx, "eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyType != KeyTypeSecp256k1 || string(pub) != string(crypto.FromECDSAPub(&secpKey.PublicKey)) {
+		t.Errorf("wrong secp256k1 public key %s %x", keyType, pub)
+	}
+	digest := crypto.Keccak256([]byte("message"))
+	sig, err := client.Sign(ctx, "eth", digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := crypto.SigToPub(digest, sig)
+	if err != nil || crypto.PubkeyToAddress(*recovered) != crypto.PubkeyToAddress(secpKey.PublicKey) {
+		t.Errorf("wrong secp256k1 signature %x", sig)
+	}
+	if _, err := client.Sign(ctx, "eth", []byte("not a digest")); err == nil {
+		t.Error("expected error for a secp256k1 message")
+	}
+
+	keyType, pub, err = client.PublicKey(ctx, "sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyType != KeyTypeEd25519 || len(pub) != ed25519.PublicKeySize {
+		t.Errorf("wrong ed25519 public key %s %x", keyType, pub)
+	}
+	sig, err = client.Sign(ctx, "sol", []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, []byte("message"), sig) {
+		t.Error("wrong ed25519 signature")
+	}
+
+	if _, _, err := client.PublicKey(ctx, "unknown"); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+	// the server error is not authenticated with another secret, only its status is reported
+	if _, err := NewClient(ts.URL, []byte("wrong")).Sign(ctx, "eth", digest); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+
+	// requests outside of the clock skew are rejected
+	defer func(skew time.Duration) { MaxClockSkew = skew }(MaxClockSkew)
+	MaxClockSkew = -time.Second
+	if _, err := client.Sign(ctx, "eth", digest); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestRemoteSignerAuthentication(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.GenerateKey()
+	server := NewServer([]byte("secret"))
+	// ids are escaped in the path
+	server.AddSecp256k1Key("hot/key 1", key)
+
+	var tamper bool
+	var lastRequest *http.Request
+	var lastBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lastRequest, lastBody = r.Clone(context.Background()), body
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if !tamper {
+			server.ServeHTTP(w, r)
+			return
+		}
+		// a man in the middle answering with its own key
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		other, _ := crypto.GenerateKey()
+		_ = json.NewEncoder(w).Encode(publicKeyResponse{Type: KeyTypeSecp256k1, PublicKey: hex.EncodeToString(crypto.FromECDSAPub(&other.PublicKey))})
+	}))
+	defer ts.Close()
+	client := NewClient(ts.URL, []byte("secret"))
+
+	_, pub, err := client.PublicKey(ctx, "hot/key 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pub, crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Errorf("wrong public key %x", pub)
+	}
+
+	// a replayed request is rejected
+	replay, _ := http.NewRequest(http.MethodPost, ts.URL+lastRequest.URL.EscapedPath(), bytes.NewReader(lastBody))
+	replay.Header = lastRequest.Header.Clone()
+	rsp, err := http.DefaultClient.Do(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("replayed request answered %s", rsp.Status)
+	}
+
+	tamper = true
+	if _, _, err := client.PublicKey(ctx, "hot/key 1"); err == nil || !strings.Contains(err.Error(), "unauthenticated response") {
+		t.Errorf("expected an unauthenticated response, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package remotesigner
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/crypto"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBodySize bounds the request bodies, large enough for any solana transaction message.
+const maxBodySize = 64 * 1024
+
+// Server is the reference remote signer. It keeps the keys in memory and is meant for local testing,
+// or as a starting point for a signer backed by an HSM or KMS.
+type Server struct {
+	secret []byte
+
+	lock      sync.RWMutex
+	secp256k1 map[string]*ecdsa.PrivateKey
+	ed25519   map[string]ed25519.PrivateKey
+
+	nonceLock sync.Mutex
+	// nonces holds the nonces of the requests of the last 2*MaxClockSkew and when they were seen
+	nonces map[string]time.Time
+}
+
+// NewServer creates a signer server accepting requests authenticated with secret.
+func NewServer(secret []byte) *Server {
+	return &Server{
+		secret:    secret,
+		secp256k1: map[string]*ecdsa.PrivateKey{},
+		ed25519:   map[string]ed25519.PrivateKey{},
+		nonces:    map[string]time.Time{},
+	}
+}
+
+// AddSecp256k1Key serves the key under id, for ethereum and filecoin signers.
+func (s *Server) AddSecp256k1Key(id string, key *ecdsa.PrivateKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.secp256k1[id] = key
+}
+
+// AddEd25519Key serves the key under id, for solana signers.
+func (s *Server) AddEd25519Key(id string, key ed25519.PrivateKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.ed25519[id] = key
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nonce := r.Header.Get(nonceHeader)
+	if r.Method != http.MethodPost {
+		s.writeError(w, nonce, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		s.writeError(w, nonce, http.StatusBadRequest, "read request")
+		return
+	}
+	// the client signs the path as sent, with the id escaped
+	path := r.URL.EscapedPath()
+	if !s.authorized(r, path, body) {
+		s.writeError(w, nonce, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/v1/keys/"), "/")
+	if !strings.HasPrefix(path, "/v1/keys/") || len(parts) != 2 {
+		s.writeError(w, nonce, http.StatusNotFound, "not found")
+		return
+	}
+	id, err := url.PathUnescape(parts[0])
+	if err != nil {
+		s.writeError(w, nonce, http.StatusNotFound, "not found")
+		return
+	}
+	op := parts[1]
+	s.lock.RLock()
+	secpKey := s.secp256k1[id]
+	edKey := s.ed25519[id]
+	s.lock.RUnlock()
+	if secpKey == nil && edKey == nil {
+		s.writeError(w, nonce, http.StatusNotFound, "unknown key "+id)
+		return
+	}
+
+	switch op {
+	case "public":
+		if secpKey != nil {
+			s.writeJSON(w, nonce, publicKeyResponse{Type: KeyTypeSecp256k1, PublicKey: hex.EncodeToString(crypto.FromECDSAPub(&secpKey.PublicKey))})
+		} else {
+			s.writeJSON(w, nonce, publicKeyResponse{Type: KeyTypeEd25519, PublicKey: hex.EncodeToString(edKey.Public().(ed25519.PublicKey))})
+		}
+	case "sign":
+		var req signRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.writeError(w, nonce, http.StatusBadRequest, "invalid request")
+			return
+		}
+		data, err := hex.DecodeString(req.Data)
+		if err != nil {
+			s.writeError(w, nonce, http.StatusBadRequest, "invalid data")
+			return
+		}
+		var sig []byte
+		if secpKey != nil {
+			if len(data) != 32 {
+				s.writeError(w, nonce, http.StatusBadRequest, "secp256k1 keys sign 32-byte digests")
+				return
+			}
+			if sig, err = crypto.Sign(data, secpKey); err != nil {
+				s.writeError(w, nonce, http.StatusInternalServerError, err.Error())
+				return
+			}
+		} else {
+			sig = ed25519.Sign(edKey, data)
+		}
+		s.writeJSON(w, nonce, signResponse{Signature: hex.EncodeToString(sig)})
+	default:
+		s.writeError(w, nonce, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) authorized(r *http.Request, path string, body []byte) bool {
+	timestamp := r.Header.Get(timestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	skew := time.Since(time.Unix(unix, 0))
+	if skew > MaxClockSkew || skew < -MaxClockSkew {
+		return false
+	}
+	nonce := r.Header.Get(nonceHeader)
+	sig, err := hex.DecodeString(r.Header.Get(signatureHeader))
+	if err != nil || nonce == "" {
+		return false
+	}
+	if !hmac.Equal(sig, authenticate(s.secret, timestamp, nonce, path, body)) {
+		return false
+	}
+	return s.useNonce(nonce)
+}
+
+// useNonce records the nonce of an authenticated request and reports whether it is new. Nonces are kept for twice
+// MaxClockSkew, after which the timestamp of a replayed request is rejected anyway.
+func (s *Server) useNonce(nonce string) bool {
+	s.nonceLock.Lock()
+	defer s.nonceLock.Unlock()
+	now := time.Now()
+	for n, seen := range s.nonces {
+		if now.Sub(seen) > 2*MaxClockSkew {
+			delete(s.nonces, n)
+		}
+	}
+	if _, ok := s.nonces[nonce]; ok {
+		return false
+	}
+	s.nonces[nonce] = now
+	return true
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, nonce string, v interface{}) {
+	s.write(w, nonce, http.StatusOK, v)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, nonce string, status int, message string) {
+	s.write(w, nonce, status, errorResponse{Error: message})
+}
+
+func (s *Server) write(w http.ResponseWriter, nonce string, status int, v interface{}) {
+	body, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(signatureHeader, hex.EncodeToString(authenticateResponse(s.secret, nonce, status, body)))
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,331 @@
+// Package shamir splits secrets and BIP-39 mnemonics into share mnemonics with two-level Shamir secret sharing
+// over GF(256), following the group layout of SLIP-39.
+//
+// The secret is split into group shares, GroupThreshold of which recover it, and each group share is split into
+// member shares, Threshold of which recover the group share. A mnemonic is shared as its BIP-39 entropy and language,
+// so combining gives back the same mnemonic and the same wallets.
+//
+// Shares are not SLIP-39 compatible. A share is the bytes
+//
+//	identifier (2) | group index, group threshold-1 (1) | group count-1, member index (1) |
+//	member threshold-1, format (1) | value length (1) | value | checksum (4)
+//
+// written as english BIP-39 words of 11 bits, zero padded. The checksum is the first 4 bytes of the SHA-256 of the
+// preceding bytes. Format 0 is a raw secret, format 1+i a mnemonic entropy in mnemonic.Languages[i]. Share values are
+// evaluations of random polynomials over GF(2^8) with the AES polynomial, at x = index+1, and the secret at x = 0.
+// The shared value is the secret followed by the first 4 bytes of its SHA-256, checked when combining.
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"github.com/icodeface/chain-kit/mnemonic"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/xerrors"
+	"math/big"
+	"strings"
+)
+
+const (
+	// MaxShares is the maximum number of groups and of members in a group.
+	MaxShares = 16
+
+	headerLength   = 6
+	checksumLength = 4
+	digestLength   = 4
+	formatRaw      = 0
+)
+
+var (
+	ErrInvalidShare       = errors.New("invalid share")
+	ErrInvalidChecksum    = errors.New("invalid share checksum")
+	ErrMismatchedShares   = errors.New("shares belong to different secrets")
+	ErrInsufficientShares = errors.New("insufficient shares")
+	ErrInvalidDigest      = errors.New("invalid secret digest, shares are corrupted")
+	ErrNotMnemonic        = errors.New("shares hold a raw secret, not a mnemonic")
+)
+
+// Group configures the member shares of a group.
+type Group struct {
+	Threshold int
+	Count     int
+}
+
+var wordIndex = map[string]int{}
+
+func init() {
+	for i, word := range wordlists.English {
+		wordIndex[word] = i
+	}
+}
+
+// Split splits secret into share mnemonics, one slice per group.
+func Split(secret []byte, groupThreshold int, groups []Group) ([][]string, error) {
+	return split(secret, formatRaw, groupThreshold, groups)
+}
+
+// Combine recovers the secret of Split.
+func Combine(shares []string) ([]byte, error) {
+	secret, _, err := combine(shares)
+	return secret, err
+}
+
+// SplitMnemonic splits a BIP-39 mnemonic into share mnemonics, one slice per group.
+func SplitMnemonic(words string, groupThreshold int, groups []Group) ([][]string, error) {
+	entropy, language, err := mnemonic.Entropy(words)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range mnemonic.Languages {
+		if l == language {
+			return split(entropy, byte(1+i), groupThreshold, groups)
+		}
+	}
+	return nil, xerrors.Errorf("unsupported language %q", language)
+}
+
+// CombineMnemonic recovers the mnemonic of SplitMnemonic.
+func CombineMnemonic(shares []string) (string, error) {
+	entropy, format, err := combine(shares)
+	if err != nil {
+		return "", err
+	}
+	if format == formatRaw || int(format) > len(mnemonic.Languages) {
+		return "", ErrNotMnemonic
+	}
+	return mnemonic.FromEntropy(entropy, mnemonic.Languages[format-1])
+}
+
+type share struct {
+	identifier      uint16
+	groupIndex      int
+	groupThreshold  int
+	groupCount      int
+	memberIndex     int
+	memberThreshold int
+	format          byte
+	value           []byte
+}
+
+func split(secret []byte, format byte, groupThreshold int, groups []Group) ([][]string, error) {
+	if len(secret) == 0 || len(secret)+digestLength > 255 {
+		return nil, xerrors.Errorf("invalid secret length %d", len(secret))
+	}
+	if len(groups) == 0 || len(groups) > MaxShares || groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, xerrors.Errorf("invalid group threshold %d of %d groups", groupThreshold, len(groups))
+	}
+	for i, g := range groups {
+		if g.Count < 1 || g.Count > MaxShares || g.Threshold < 1 || g.Threshold > g.Count {
+			return nil, xerrors.Errorf("invalid threshold %d of %d shares in group %d", g.Threshold, g.Count, i)
+		}
+	}
+	id := make([]byte, 2)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(secret)
+	value := append(append([]byte{}, secret...), digest[:digestLength]...)
+
+	groupValues, err := splitValue(value, groupThreshold, len(groups))
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]string, len(groups))
+	for gi, g := range groups {
+		memberValues, err := splitValue(groupValues[gi], g.Threshold, g.Count)
+		if err != nil {
+			return nil, err
+		}
+		for mi, v := range memberValues {
+			result[gi] = append(result[gi], encode(&share{
+				identifier:      binary.BigEndian.Uint16(id),
+				groupIndex:      gi,
+				groupThreshold:  groupThreshold,
+				groupCount:      len(groups),
+				memberIndex:     mi,
+				memberThreshold: g.Threshold,
+				format:          format,
+				value:           v,
+			}))
+		}
+	}
+	return result, nil
+}
+
+func combine(mnemonics []string) ([]byte, byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, 0, ErrInsufficientShares
+	}
+	shares := make([]*share, len(mnemonics))
+	for i, m := range mnemonics {
+		s, err := decode(m)
+		if err != nil {
+			return nil, 0, xerrors.Errorf("share %d: %w", i, err)
+		}
+		first := shares[0]
+		if first == nil {
+			first = s
+		}
+		if s.identifier != first.identifier || s.groupThreshold != first.groupThreshold ||
+			s.groupCount != first.groupCount || s.format != first.format || len(s.value) != len(first.value) {
+			return nil, 0, ErrMismatchedShares
+		}
+		shares[i] = s
+	}
+
+	// recover the group shares with enough members
+	members := map[int]map[int][]byte{}
+	thresholds := map[int]int{}
+	for _, s := range shares {
+		if t, ok := thresholds[s.groupIndex]; ok && t != s.memberThreshold {
+			return nil, 0, ErrMismatchedShares
+		}
+		thresholds[s.groupIndex] = s.memberThreshold
+		if members[s.groupIndex] == nil {
+			members[s.groupIndex] = map[int][]byte{}
+		}
+		members[s.groupIndex][s.memberIndex] = s.value
+	}
+	groups := map[int][]byte{}
+	for gi, values := range members {
+		if len(values) >= thresholds[gi] {
+			groups[gi] = interpolate(values, thresholds[gi])
+		}
+	}
+	if len(groups) < shares[0].groupThreshold {
+		return nil, 0, xerrors.Errorf("%w: %d of %d groups complete", ErrInsufficientShares, len(groups), shares[0].groupThreshold)
+	}
+
+	value := interpolate(groups, shares[0].groupThreshold)
+	secret, digest := value[:len(value)-digestLength], value[len(value)-digestLength:]
+	expected := sha256.Sum256(secret)
+	if !bytes.Equal(digest, expected[:digestLength]) {
+		return nil, 0, ErrInvalidDigest
+	}
+	return secret, shares[0].format, nil
+}
+
+// splitValue evaluates threshold-1 degree random polynomials with the value bytes as constant terms.
+func splitValue(value []byte, threshold int, count int) ([][]byte, error) {
+	coefficients := make([]byte, len(value)*(threshold-1))
+	if _, err := rand.Read(coefficients); err != nil {
+		return nil, err
+	}
+	shares := make([][]byte, count)
+	for i := range shares {
+		x := byte(i + 1)
+		shares[i] = make([]byte, len(value))
+		for b := range value {
+			// Horner's rule, from the highest degree coefficient
+			var y byte
+			for d := threshold - 2; d >= 0; d-- {
+				y = gfMul(y, x) ^ coefficients[b*(threshold-1)+d]
+			}
+			shares[i][b] = gfMul(y, x) ^ value[b]
+		}
+	}
+	return shares, nil
+}
+
+// interpolate recovers the constant terms from threshold of the values, keyed by index.
+func interpolate(values map[int][]byte, threshold int) []byte {
+	var xs []byte
+	var ys [][]byte
+	for index, y := range values {
+		if len(xs) == threshold {
+			break
+		}
+		xs = append(xs, byte(index+1))
+		ys = append(ys, y)
+	}
+	result := make([]byte, len(ys[0]))
+	for i, xi := range xs {
+		// Lagrange basis polynomial of xi at 0
+		basis := byte(1)
+		for j, xj := range xs {
+			if i != j {
+				basis = gfMul(basis, gfDiv(xj, xi^xj))
+			}
+		}
+		for b := range result {
+			result[b] ^= gfMul(ys[i][b], basis)
+		}
+	}
+	return result
+}
+
+func encode(s *share) string {
+	data := make([]byte, headerLength, headerLength+len(s.value)+checksumLength)
+	binary.BigEndian.PutUint16(data, s.identifier)
+	data[2] = byte(s.groupIndex<<4 | (s.groupThreshold - 1))
+	data[3] = byte((s.groupCount-1)<<4 | s.memberIndex)
+	data[4] = (byte(s.memberThreshold-1) << 4) | s.format
+	data[5] = byte(len(s.value))
+	data = append(data, s.value...)
+	checksum := sha256.Sum256(data)
+	data = append(data, checksum[:checksumLength]...)
+
+	words := make([]string, (len(data)*8+10)/11)
+	n := new(big.Int).SetBytes(data)
+	n.Lsh(n, uint(len(words)*11-len(data)*8))
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = wordlists.English[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+func decode(m string) (*share, error) {
+	words := strings.Fields(m)
+	n := new(big.Int)
+	for _, word := range words {
+		i, ok := wordIndex[word]
+		if !ok {
+			return nil, xerrors.Errorf("%w: unknown word %q", ErrInvalidShare, word)
+		}
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(i)))
+	}
+	bits := len(words) * 11
+	if bits < (headerLength+checksumLength)*8 {
+		return nil, ErrInvalidShare
+	}
+	// the length byte gives the size of the data, the rest of the bits are zero padding
+	n.Rsh(n, uint(bits%8))
+	data := make([]byte, bits/8)
+	n.FillBytes(data)
+	// the value holds the secret followed by its digest, so it is longer than the digest
+	if int(data[5]) <= digestLength {
+		return nil, ErrInvalidShare
+	}
+	length := headerLength + int(data[5]) + checksumLength
+	if len(data) == length+1 && data[length] == 0 {
+		data = data[:length]
+	}
+	if len(data) != length {
+		return nil, ErrInvalidShare
+	}
+	body, checksum := data[:len(data)-checksumLength], data[len(data)-checksumLength:]
+	expected := sha256.Sum256(body)
+	if !bytes.Equal(checksum, expected[:checksumLength]) {
+		return nil, ErrInvalidChecksum
+	}
+	s := &share{
+		identifier:      binary.BigEndian.Uint16(data),
+		groupIndex:      int(data[2] >> 4),
+		groupThreshold:  int(data[2]&0x0f) + 1,
+		groupCount:      int(data[3]>>4) + 1,
+		memberIndex:     int(data[3] & 0x0f),
+		memberThreshold: int(data[4]>>4) + 1,
+		format:          data[4] & 0x0f,
+		value:           body[headerLength:],
+	}
+	if s.groupIndex >= s.groupCount || s.groupThreshold > s.groupCount {
+		return nil, ErrInvalidShare
+	}
+	return s, nil
+}
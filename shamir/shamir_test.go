@@ -0,0 +1,127 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"github.com/icodeface/chain-kit/mnemonic"
+	"strings"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	for length := 16; length <= 32; length++ {
+		secret := bytes.Repeat([]byte{byte(length)}, length)
+		groups, err := Split(secret, 1, []Group{{Threshold: 3, Count: 5}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares := groups[0]
+		for _, subset := range [][]string{shares[:3], shares[2:], {shares[4], shares[0], shares[2]}} {
+			recovered, err := Combine(subset)
+			if err != nil {
+				t.Fatalf("%d bytes: %v", length, err)
+			}
+			if !bytes.Equal(recovered, secret) {
+				t.Errorf("%d bytes: wrong secret %x", length, recovered)
+			}
+		}
+		if _, err := Combine(shares[:2]); !errors.Is(err, ErrInsufficientShares) {
+			t.Errorf("%d bytes: expected ErrInsufficientShares, got %v", length, err)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	groups, err := Split(secret, 2, []Group{{Threshold: 1, Count: 1}, {Threshold: 2, Count: 3}, {Threshold: 3, Count: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := Combine([]string{groups[0][0], groups[2][1], groups[2][3], groups[2][4]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(recovered, secret) {
+		t.Errorf("wrong secret %x", recovered)
+	}
+	// two groups, but the second one is incomplete
+	if _, err := Combine([]string{groups[0][0], groups[1][0], groups[2][0]}); !errors.Is(err, ErrInsufficientShares) {
+		t.Errorf("expected ErrInsufficientShares, got %v", err)
+	}
+
+	other, err := Split(secret, 2, []Group{{Threshold: 1, Count: 1}, {Threshold: 1, Count: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Combine([]string{groups[0][0], other[1][0]}); err != ErrMismatchedShares {
+		t.Errorf("expected ErrMismatchedShares, got %v", err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	groups, err := Split([]byte("0123456789abcdef"), 1, []Group{{Threshold: 2, Count: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Fields(groups[0][0])
+	if words[8] == "abandon" {
+		words[8] = "ability"
+	} else {
+		words[8] = "abandon"
+	}
+	if _, err := Combine([]string{strings.Join(words, " "), groups[0][1]}); !errors.Is(err, ErrInvalidChecksum) {
+		t.Errorf("expected ErrInvalidChecksum, got %v", err)
+	}
+}
+
+func TestShortShare(t *testing.T) {
+	// a share with a valid checksum but a value without room for the digest
+	for _, value := range [][]byte{nil, {1, 2, 3, 4}} {
+		m := encode(&share{identifier: 1, groupThreshold: 1, groupCount: 1, memberThreshold: 1, value: value})
+		if _, err := Combine([]string{m}); !errors.Is(err, ErrInvalidShare) {
+			t.Errorf("%d bytes value: expected ErrInvalidShare, got %v", len(value), err)
+		}
+	}
+}
+
+func TestMnemonic(t *testing.T) {
+	for _, language := range []mnemonic.Language{mnemonic.English, mnemonic.Japanese, mnemonic.Spanish} {
+		words, err := mnemonic.Generate(256, language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups, err := SplitMnemonic(words, 1, []Group{{Threshold: 2, Count: 3}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recovered, err := CombineMnemonic(groups[0][1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recovered != words {
+			t.Errorf("%s: wrong mnemonic %q", language, recovered)
+		}
+	}
+
+	groups, err := Split([]byte("0123456789abcdef"), 1, []Group{{Threshold: 1, Count: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CombineMnemonic(groups[0]); err != ErrNotMnemonic {
+		t.Errorf("expected ErrNotMnemonic, got %v", err)
+	}
+}
+
+func TestGF256(t *testing.T) {
+	// FIPS-197 example
+	if gfMul(0x57, 0x83) != 0xc1 {
+		t.Errorf("wrong product %x", gfMul(0x57, 0x83))
+	}
+	for a := 0; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if gfMul(gfDiv(byte(a), byte(b)), byte(b)) != byte(a) {
+				t.Fatalf("%x / %x * %x != %x", a, b, b, a)
+			}
+		}
+	}
+}
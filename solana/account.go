@@ -12,6 +12,9 @@ import (
 type Account struct {
 	PrivateKey solana.PrivateKey
 	Address    string
+
+	// Signer, if set, signs for the account instead of PrivateKey, which may then be nil.
+	Signer Signer
 }
 
 func AccountFromPrivateKey(rawKey []byte) *Account {
@@ -25,28 +28,60 @@ func AccountFromPrivateKey(rawKey []byte) *Account {
 }
 
 func (account *Account) PublicKey() solana.PublicKey {
-	return account.PrivateKey.PublicKey()
+	return account.signer().PublicKey()
 }
 
-func (account *Account) Transfer(rpcClient *Client, to string, amount *big.Int) (*solana.Signature, error) {
+func (account *Account) Transfer(rpcClient *Client, to string, amount *big.Int, opts ...TransferOption) (*solana.Signature, error) {
+	tx, err := account.NewTransferTransaction(rpcClient, to, amount, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return SendTransaction(rpcClient, tx)
+}
+
+// NewTransferTransaction builds and signs the transaction sent by Transfer, so it can be previewed with Simulate first.
+func (account *Account) NewTransferTransaction(rpcClient *Client, to string, amount *big.Int, opts ...TransferOption) (*solana.Transaction, error) {
 	accountTo, err := solana.PublicKeyFromBase58(to)
 	if err != nil {
 		return nil, err
 	}
+	options := &transferOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	instructions := []solana.Instruction{
+		system.NewTransferInstruction(
+			amount.Uint64(),
+			account.PublicKey(),
+			accountTo,
+		).Build(),
+	}
+	if options.memo != nil {
+		memo, err := NewMemoInstruction(*options.memo, account.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, memo)
+	}
+	return account.NewTransaction(rpcClient, instructions...)
+}
+
+// NewTransaction builds a transaction paid for by the account with a recent blockhash and signs it.
+// Instructions must not require signatures from other accounts.
+func (account *Account) NewTransaction(rpcClient *Client, instructions ...solana.Instruction) (*solana.Transaction, error) {
+	return account.NewTransactionContext(context.TODO(), rpcClient, instructions...)
+}
 
-	recent, err := rpcClient.GetRecentBlockhash(context.TODO(), rpc.CommitmentFinalized)
+// NewTransactionContext is NewTransaction with a context for the requests and the Signer of the account.
+func (account *Account) NewTransactionContext(ctx context.Context, rpcClient *Client, instructions ...solana.Instruction) (*solana.Transaction, error) {
+	recent, err := rpcClient.GetRecentBlockhash(ctx, rpc.CommitmentFinalized)
 	if err != nil {
 		return nil, err
 	}
 
 	tx, err := solana.NewTransaction(
-		[]solana.Instruction{
-			system.NewTransferInstruction(
-				amount.Uint64(),
-				account.PublicKey(),
-				accountTo,
-			).Build(),
-		},
+		instructions,
 		recent.Value.Blockhash,
 		solana.TransactionPayer(account.PublicKey()),
 	)
@@ -54,19 +89,32 @@ func (account *Account) Transfer(rpcClient *Client, to string, amount *big.Int)
 		return nil, err
 	}
 
-	_, err = tx.Sign(
-		func(key solana.PublicKey) *solana.PrivateKey {
-			if account.PublicKey().Equals(key) {
-				return &account.PrivateKey
-			}
-			return nil
-		},
-	)
-	if err != nil {
+	if err := account.signTransaction(ctx, tx); err != nil {
 		return nil, fmt.Errorf("unable to sign transaction: %w", err)
 	}
+	return tx, nil
+}
+
+// SendInstructions builds a transaction from instructions with NewTransaction and sends it.
+func (account *Account) SendInstructions(rpcClient *Client, instructions ...solana.Instruction) (*solana.Signature, error) {
+	return account.SendInstructionsContext(context.TODO(), rpcClient, instructions...)
+}
+
+// SendInstructionsContext is SendInstructions with a context for the requests and the Signer of the account.
+func (account *Account) SendInstructionsContext(ctx context.Context, rpcClient *Client, instructions ...solana.Instruction) (*solana.Signature, error) {
+	tx, err := account.NewTransactionContext(ctx, rpcClient, instructions...)
+	if err != nil {
+		return nil, err
+	}
+	return sendTransaction(ctx, rpcClient, tx)
+}
+
+// SendTransaction sends a signed transaction with preflight checks.
+func SendTransaction(rpcClient *Client, tx *solana.Transaction) (*solana.Signature, error) {
+	return sendTransaction(context.TODO(), rpcClient, tx)
+}
 
-	// Send transaction
-	sig, err := rpcClient.SendTransactionWithOpts(context.TODO(), tx, false, rpc.CommitmentFinalized)
+func sendTransaction(ctx context.Context, rpcClient *Client, tx *solana.Transaction) (*solana.Signature, error) {
+	sig, err := rpcClient.SendTransactionWithOpts(ctx, tx, false, rpc.CommitmentFinalized)
 	return &sig, err
 }
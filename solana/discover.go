@@ -0,0 +1,69 @@
+package solana
+
+import (
+	"context"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/icodeface/chain-kit/discovery"
+	"github.com/icodeface/hdkeyring"
+)
+
+// PathScheme is a derivation path layout used by wallets.
+type PathScheme = discovery.PathScheme
+
+// DiscoveredAccount is a used address found by Discovery, with the base58 address.
+type DiscoveredAccount = discovery.Account
+
+var (
+	// SchemeDefault is the layout of DerivePath(i).
+	SchemeDefault = PathScheme{Name: "default", Format: "m/44'/501'/0'/%d'"}
+	// SchemeCLI is used by the Solana CLI, Phantom and Solflare.
+	SchemeCLI = PathScheme{Name: "cli", Format: "m/44'/501'/%d'/0'"}
+	// SchemeLedger is used by Ledger Live and Trust Wallet.
+	SchemeLedger = PathScheme{Name: "ledger", Format: "m/44'/501'/%d'"}
+)
+
+// Discovery finds the used addresses of a wallet. For each scheme it walks the indexes until GapLimit
+// consecutive addresses have neither balance nor transaction signatures.
+type Discovery struct {
+	GapLimit int
+	Schemes  []PathScheme
+
+	client *Client
+}
+
+func NewDiscovery(client *Client) *Discovery {
+	return &Discovery{
+		GapLimit: discovery.DefaultGapLimit,
+		Schemes:  []PathScheme{SchemeDefault, SchemeCLI, SchemeLedger},
+		client:   client,
+	}
+}
+
+// Discover returns the used addresses of the wallet, by scheme and index.
+func (d *Discovery) Discover(ctx context.Context, wallet *Wallet) ([]*DiscoveredAccount, error) {
+	return discovery.Walk(ctx, d.Schemes, d.GapLimit, func(ctx context.Context, path hdkeyring.DerivationPath) (string, bool, error) {
+		account, err := wallet.DeriveAccount(path)
+		if err != nil {
+			return "", false, err
+		}
+		used, err := d.used(ctx, account.PublicKey())
+		return account.Address, used, err
+	})
+}
+
+func (d *Discovery) used(ctx context.Context, pubkey solana.PublicKey) (bool, error) {
+	balance, err := d.client.GetBalance(ctx, pubkey, rpc.CommitmentFinalized)
+	if err != nil {
+		return false, err
+	}
+	if balance.Value > 0 {
+		return true, nil
+	}
+	limit := 1
+	signatures, err := d.client.GetSignaturesForAddressWithOpts(ctx, pubkey, &rpc.GetSignaturesForAddressOpts{Limit: &limit})
+	if err != nil {
+		return false, err
+	}
+	return len(signatures) > 0, nil
+}
@@ -0,0 +1,51 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gagliardetto/solana-go"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscovery_Used(t *testing.T) {
+	funded := solana.MustPublicKeyFromBase58("6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy76a")
+	history := solana.MustPublicKeyFromBase58("Vote111111111111111111111111111111111111111")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		addr := req.Params[0].(string)
+		var result interface{}
+		switch req.Method {
+		case "getBalance":
+			lamports := 0
+			if addr == funded.String() {
+				lamports = 1000000
+			}
+			result = map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": lamports}
+		case "getSignaturesForAddress":
+			signatures := []interface{}{}
+			if addr == history.String() {
+				signatures = append(signatures, map[string]interface{}{
+					"signature": "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW",
+					"slot":      1,
+				})
+			}
+			result = signatures
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	discovery := NewDiscovery(NewClient(server.URL))
+	for pubkey, expected := range map[solana.PublicKey]bool{funded: true, history: true, StakeProgramID: false} {
+		if used, err := discovery.used(context.Background(), pubkey); err != nil || used != expected {
+			t.Errorf("%s: expected used %v, got %v %v", pubkey, expected, used, err)
+		}
+	}
+}
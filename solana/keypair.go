@@ -0,0 +1,113 @@
+package solana
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/xerrors"
+	"io/ioutil"
+)
+
+// AccountFromSeed creates an account from a 32-byte ed25519 seed.
+func AccountFromSeed(seed []byte) (*Account, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, xerrors.Errorf("invalid seed length: %d", len(seed))
+	}
+	return AccountFromPrivateKey(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// AccountFromSecretKey creates an account from a 64-byte secret key (seed followed by public key)
+// and checks that the embedded public key matches the seed.
+func AccountFromSecretKey(secret []byte) (*Account, error) {
+	if err := validateSecretKey(secret); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, len(secret))
+	copy(raw, secret)
+	return AccountFromPrivateKey(raw), nil
+}
+
+// AccountFromBase58 creates an account from a base58 encoded secret key, as exported by Phantom or Solflare.
+func AccountFromBase58(secret string) (*Account, error) {
+	priv, err := solana.PrivateKeyFromBase58(secret)
+	if err != nil {
+		return nil, xerrors.Errorf("decode base58 secret: %w", err)
+	}
+	return AccountFromSecretKey(priv)
+}
+
+// AccountFromKeygenJSON creates an account from the content of a solana-keygen keypair file (a JSON array of 64 bytes).
+func AccountFromKeygenJSON(data []byte) (*Account, error) {
+	// encoding/json decodes []byte from a base64 string, so read the array as integers
+	var ints []uint16
+	if err := json.Unmarshal(data, &ints); err != nil {
+		return nil, xerrors.Errorf("decode keypair json: %w", err)
+	}
+	values := make([]byte, len(ints))
+	for i, v := range ints {
+		if v > 0xff {
+			return nil, xerrors.Errorf("invalid keypair byte: %d", v)
+		}
+		values[i] = byte(v)
+	}
+	return AccountFromSecretKey(values)
+}
+
+// AccountFromKeygenFile reads a solana-keygen keypair file, such as ~/.config/solana/id.json.
+func AccountFromKeygenFile(file string) (*Account, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, xerrors.Errorf("read keypair file: %w", err)
+	}
+	return AccountFromKeygenJSON(data)
+}
+
+// Seed returns the 32-byte ed25519 seed of the account.
+func (account *Account) Seed() ([]byte, error) {
+	if account.PrivateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	return ed25519.PrivateKey(account.PrivateKey).Seed(), nil
+}
+
+// Base58 returns the base58 encoded 64-byte secret key.
+func (account *Account) Base58() (string, error) {
+	if account.PrivateKey == nil {
+		return "", ErrNoPrivateKey
+	}
+	return account.PrivateKey.String(), nil
+}
+
+// KeygenJSON returns the secret key in the solana-keygen keypair file format.
+func (account *Account) KeygenJSON() ([]byte, error) {
+	if account.PrivateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	ints := make([]uint16, len(account.PrivateKey))
+	for i, b := range account.PrivateKey {
+		ints[i] = uint16(b)
+	}
+	return json.Marshal(ints)
+}
+
+// WriteKeygenFile writes the secret key to a solana-keygen keypair file readable only by the owner.
+func (account *Account) WriteKeygenFile(file string) error {
+	data, err := account.KeygenJSON()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0600)
+}
+
+func validateSecretKey(secret []byte) error {
+	if len(secret) != ed25519.PrivateKeySize {
+		return xerrors.Errorf("invalid secret key length: %d", len(secret))
+	}
+	expected := ed25519.NewKeyFromSeed(secret[:ed25519.SeedSize]).Public().(ed25519.PublicKey)
+	if !bytes.Equal(expected, secret[ed25519.SeedSize:]) {
+		return errors.New("public key does not match secret key")
+	}
+	return nil
+}
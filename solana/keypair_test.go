@@ -0,0 +1,85 @@
+package solana
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeypairFormats(t *testing.T) {
+	priv, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := AccountFromPrivateKey(priv)
+
+	seed, err := account.Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSeed, err := AccountFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromSeed.Address != account.Address {
+		t.Error("seed import gives wrong address")
+	}
+
+	secret, err := account.Base58()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromBase58, err := AccountFromBase58(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromBase58.Address != account.Address {
+		t.Error("base58 import gives wrong address")
+	}
+
+	file := filepath.Join(t.TempDir(), "id.json")
+	if err := account.WriteKeygenFile(file); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := AccountFromKeygenFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromFile.Address != account.Address {
+		t.Error("keygen file import gives wrong address")
+	}
+}
+
+func TestAccountFromSecretKeyMismatch(t *testing.T) {
+	priv, _ := solana.NewRandomPrivateKey()
+	other, _ := solana.NewRandomPrivateKey()
+	forged := append(append([]byte{}, priv[:32]...), other[32:]...)
+	if _, err := AccountFromSecretKey(forged); err == nil {
+		t.Error("expected public key mismatch error")
+	}
+	if _, err := AccountFromKeygenJSON([]byte("[1,2,3]")); err == nil {
+		t.Error("expected length error")
+	}
+}
+
+func TestKeypairFormatsWithoutPrivateKey(t *testing.T) {
+	priv, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := AccountFromSigner(NewKeySigner(priv))
+	if _, err := account.Seed(); err != ErrNoPrivateKey {
+		t.Errorf("Seed: expected ErrNoPrivateKey, got %v", err)
+	}
+	if _, err := account.Base58(); err != ErrNoPrivateKey {
+		t.Errorf("Base58: expected ErrNoPrivateKey, got %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "id.json")
+	if err := account.WriteKeygenFile(file); err != ErrNoPrivateKey {
+		t.Errorf("WriteKeygenFile: expected ErrNoPrivateKey, got %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("keygen file written without a private key")
+	}
+}
@@ -0,0 +1,67 @@
+package solana
+
+import (
+	"errors"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"strings"
+	"unicode/utf8"
+)
+
+// MemoV1ProgramID is the legacy Memo program, still used by some exchanges.
+var MemoV1ProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+type transferOptions struct {
+	memo *string
+}
+
+// TransferOption customizes the transaction built by Account.Transfer.
+type TransferOption func(opts *transferOptions)
+
+// WithMemo attaches an SPL Memo instruction, e.g. the deposit tag required by an exchange.
+func WithMemo(memo string) TransferOption {
+	return func(opts *transferOptions) {
+		opts.memo = &memo
+	}
+}
+
+// NewMemoInstruction creates an SPL Memo instruction. The memo program verifies that signers signed the transaction.
+func NewMemoInstruction(memo string, signers ...solana.PublicKey) (solana.Instruction, error) {
+	if !utf8.ValidString(memo) {
+		return nil, errors.New("memo is not valid UTF-8")
+	}
+	accounts := make([]*solana.AccountMeta, len(signers))
+	for i, signer := range signers {
+		accounts[i] = solana.Meta(signer).SIGNER()
+	}
+	return solana.NewInstruction(solana.MemoProgramID, accounts, []byte(memo)), nil
+}
+
+// ParseMemos returns the memos of the top-level and, when meta is not nil, inner memo instructions of the transaction.
+func ParseMemos(tx *solana.Transaction, meta *rpc.TransactionMeta) []string {
+	var memos []string
+	parse := func(inst solana.CompiledInstruction) {
+		program, err := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+		if err != nil {
+			return
+		}
+		if program.Equals(solana.MemoProgramID) || program.Equals(MemoV1ProgramID) {
+			memos = append(memos, string(inst.Data))
+		}
+	}
+	for _, inst := range tx.Message.Instructions {
+		parse(inst)
+	}
+	if meta != nil {
+		for _, inner := range meta.InnerInstructions {
+			for _, inst := range inner.Instructions {
+				parse(inst)
+			}
+		}
+	}
+	return memos
+}
+
+func joinMemos(memos []string) string {
+	return strings.Join(memos, "; ")
+}
@@ -0,0 +1,137 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/xerrors"
+	"unicode/utf8"
+)
+
+// OffchainMessageFormat is the message format of the off-chain message header.
+type OffchainMessageFormat uint8
+
+const (
+	// OffchainMessageRestrictedASCII is printable ASCII of at most OffchainMessageMaxLedgerLength bytes.
+	OffchainMessageRestrictedASCII OffchainMessageFormat = 0
+	// OffchainMessageLimitedUTF8 is UTF-8 of at most OffchainMessageMaxLedgerLength bytes.
+	OffchainMessageLimitedUTF8 OffchainMessageFormat = 1
+	// OffchainMessageExtendedUTF8 is UTF-8 of at most OffchainMessageMaxLength bytes.
+	OffchainMessageExtendedUTF8 OffchainMessageFormat = 2
+)
+
+const (
+	offchainMessageHeaderLength = 16 + 1 + 1 + 2
+
+	// OffchainMessageMaxLedgerLength is the longest message hardware wallets can sign (packet size minus header).
+	OffchainMessageMaxLedgerLength = 1232 - offchainMessageHeaderLength
+	// OffchainMessageMaxLength is the longest off-chain message.
+	OffchainMessageMaxLength = 65535 - offchainMessageHeaderLength
+)
+
+// OffchainMessageSigningDomain prefixes every off-chain message, it can never be a valid transaction message.
+var OffchainMessageSigningDomain = []byte("\xffsolana offchain")
+
+// SignMessage signs arbitrary bytes with the account key.
+func (account *Account) SignMessage(message []byte) (solana.Signature, error) {
+	return account.SignMessageContext(context.Background(), message)
+}
+
+// SignMessageContext is SignMessage with a context for the Signer of the account.
+func (account *Account) SignMessageContext(ctx context.Context, message []byte) (solana.Signature, error) {
+	return account.signer().SignMessage(ctx, message)
+}
+
+// VerifyMessage checks that signature was made over message by the key of address.
+func VerifyMessage(address string, message []byte, signature solana.Signature) (bool, error) {
+	pub, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return false, xerrors.Errorf("invalid address: %w", err)
+	}
+	return ed25519.Verify(pub[:], message, signature[:]), nil
+}
+
+// EncodeOffchainMessage serializes message in the version 0 off-chain message format:
+// signing domain, header version, message format and little endian u16 length, followed by the message.
+func EncodeOffchainMessage(message string) ([]byte, error) {
+	format, err := offchainMessageFormat(message)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, offchainMessageHeaderLength+len(message))
+	data = append(data, OffchainMessageSigningDomain...)
+	data = append(data, 0, byte(format))
+	data = append(data, 0, 0)
+	binary.LittleEndian.PutUint16(data[len(data)-2:], uint16(len(message)))
+	data = append(data, message...)
+	return data, nil
+}
+
+// DecodeOffchainMessage parses a version 0 off-chain message and returns its text.
+func DecodeOffchainMessage(data []byte) (string, error) {
+	if len(data) < offchainMessageHeaderLength || !bytes.HasPrefix(data, OffchainMessageSigningDomain) {
+		return "", errors.New("not an off-chain message")
+	}
+	header := data[len(OffchainMessageSigningDomain):]
+	if header[0] != 0 {
+		return "", xerrors.Errorf("unsupported off-chain message version: %d", header[0])
+	}
+	length := int(binary.LittleEndian.Uint16(header[2:4]))
+	message := data[offchainMessageHeaderLength:]
+	if len(message) != length {
+		return "", xerrors.Errorf("invalid off-chain message length: %d != %d", len(message), length)
+	}
+	format, err := offchainMessageFormat(string(message))
+	if err != nil {
+		return "", err
+	}
+	if format != OffchainMessageFormat(header[1]) {
+		return "", xerrors.Errorf("invalid off-chain message format: %d", header[1])
+	}
+	return string(message), nil
+}
+
+// SignOffchainMessage signs message in the off-chain message format, as `solana sign-offchain-message` does.
+func (account *Account) SignOffchainMessage(message string) (solana.Signature, error) {
+	return account.SignOffchainMessageContext(context.Background(), message)
+}
+
+// SignOffchainMessageContext is SignOffchainMessage with a context for the Signer of the account.
+func (account *Account) SignOffchainMessageContext(ctx context.Context, message string) (solana.Signature, error) {
+	data, err := EncodeOffchainMessage(message)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	return account.SignMessageContext(ctx, data)
+}
+
+// VerifyOffchainMessage checks a signature made by SignOffchainMessage.
+func VerifyOffchainMessage(address string, message string, signature solana.Signature) (bool, error) {
+	data, err := EncodeOffchainMessage(message)
+	if err != nil {
+		return false, err
+	}
+	return VerifyMessage(address, data, signature)
+}
+
+func offchainMessageFormat(message string) (OffchainMessageFormat, error) {
+	switch {
+	case len(message) == 0:
+		return 0, errors.New("empty off-chain message")
+	case len(message) > OffchainMessageMaxLength:
+		return 0, xerrors.Errorf("off-chain message too long: %d bytes", len(message))
+	case !utf8.ValidString(message):
+		return 0, errors.New("off-chain message is not valid UTF-8")
+	case len(message) > OffchainMessageMaxLedgerLength:
+		return OffchainMessageExtendedUTF8, nil
+	}
+	for i := 0; i < len(message); i++ {
+		if message[i] < 0x20 || message[i] > 0x7e {
+			return OffchainMessageLimitedUTF8, nil
+		}
+	}
+	return OffchainMessageRestrictedASCII, nil
+}
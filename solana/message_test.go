@@ -0,0 +1,58 @@
+package solana
+
+import (
+	"bytes"
+	"github.com/gagliardetto/solana-go"
+	"testing"
+)
+
+func TestSignMessage(t *testing.T) {
+	priv, _ := solana.NewRandomPrivateKey()
+	account := AccountFromPrivateKey(priv)
+
+	sig, err := account.SignMessage([]byte("challenge"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMessage(account.Address, []byte("challenge"), sig); err != nil || !ok {
+		t.Error("signature should verify")
+	}
+	if ok, _ := VerifyMessage(account.Address, []byte("other"), sig); ok {
+		t.Error("signature should not verify another message")
+	}
+
+	sig, err = account.SignOffchainMessage("withdrawal whitelist: 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyOffchainMessage(account.Address, "withdrawal whitelist: 42", sig); err != nil || !ok {
+		t.Error("off-chain signature should verify")
+	}
+	if ok, _ := VerifyMessage(account.Address, []byte("withdrawal whitelist: 42"), sig); ok {
+		t.Error("off-chain signature should not verify the raw message")
+	}
+}
+
+func TestEncodeOffchainMessage(t *testing.T) {
+	data, err := EncodeOffchainMessage("Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte("\xffsolana offchain"), 0, 0, 5, 0, 'H', 'e', 'l', 'l', 'o')
+	if !bytes.Equal(data, expected) {
+		t.Errorf("wrong encoding %x", data)
+	}
+
+	data, _ = EncodeOffchainMessage("héllo")
+	if data[17] != byte(OffchainMessageLimitedUTF8) {
+		t.Error("expected limited UTF-8 format")
+	}
+	message, err := DecodeOffchainMessage(data)
+	if err != nil || message != "héllo" {
+		t.Errorf("wrong decoded message %q: %v", message, err)
+	}
+
+	if _, err := EncodeOffchainMessage(""); err == nil {
+		t.Error("expected error for empty message")
+	}
+}
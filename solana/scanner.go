@@ -0,0 +1,453 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/xerrors"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Token2022ProgramID is the SPL Token-2022 program, its transfer instructions share the layout of the Token program.
+var Token2022ProgramID = solana.MustPublicKeyFromBase58("TokenzQdBNbLqP5VEhdkAS6EPFLC1PHnBqCXEpPxuEb")
+
+const (
+	systemInstructionTransfer         = 2
+	systemInstructionTransferWithSeed = 11
+
+	tokenInstructionTransfer        = 3
+	tokenInstructionTransferChecked = 12
+)
+
+// Credit is an incoming SOL or SPL token transfer to a watched address.
+type Credit struct {
+	Signature solana.Signature
+	Slot      uint64
+	BlockTime *solana.UnixTimeSeconds
+	// Address is the watched address that was credited.
+	Address solana.PublicKey
+	// Account is the account receiving the funds: Address itself for SOL, a token account for SPL tokens.
+	Account solana.PublicKey
+	// Mint is the zero public key for SOL.
+	Mint     solana.PublicKey
+	Amount   *big.Int
+	Decimals uint8
+	// Sender is the sending wallet, for SPL tokens the owner of the source token account when known.
+	Sender solana.PublicKey
+	// Memo holds the memos attached to the transaction, joined with "; ".
+	Memo string
+	// Instruction is the index of the top-level instruction, Inner the index inside its inner instructions or -1.
+	Instruction int
+	Inner       int
+}
+
+// IsNative reports whether the credit is in SOL.
+func (c *Credit) IsNative() bool {
+	return c.Mint.IsZero()
+}
+
+// Scanner turns transaction history of watched addresses into credits.
+// Watched addresses are wallet addresses; SPL credits to token accounts they own are reported too.
+type Scanner struct {
+	client *Client
+
+	// Commitment used for every request, finalized by default so checkpoints never need to be rolled back.
+	Commitment rpc.CommitmentType
+	// PageSize is the number of signatures requested per getSignaturesForAddress call.
+	PageSize int
+
+	lock    sync.RWMutex
+	watched map[solana.PublicKey]bool
+}
+
+func NewScanner(client *Client, addresses ...solana.PublicKey) *Scanner {
+	s := &Scanner{
+		client:     client,
+		Commitment: rpc.CommitmentFinalized,
+		PageSize:   1000,
+		watched:    map[solana.PublicKey]bool{},
+	}
+	s.Watch(addresses...)
+	return s
+}
+
+// Watch adds addresses to the watch list.
+func (s *Scanner) Watch(addresses ...solana.PublicKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, addr := range addresses {
+		s.watched[addr] = true
+	}
+}
+
+// Unwatch removes addresses from the watch list.
+func (s *Scanner) Unwatch(addresses ...solana.PublicKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, addr := range addresses {
+		delete(s.watched, addr)
+	}
+}
+
+// Watched returns the watch list.
+func (s *Scanner) Watched() []solana.PublicKey {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	list := make([]solana.PublicKey, 0, len(s.watched))
+	for addr := range s.watched {
+		list = append(list, addr)
+	}
+	return list
+}
+
+func (s *Scanner) isWatched(addr solana.PublicKey) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.watched[addr]
+}
+
+// Scan walks the signature history of every watched address and of the token accounts it owns for transactions
+// in slots after checkpoint, and returns their credits in slot order together with the next checkpoint.
+// Failed transactions are skipped.
+func (s *Scanner) Scan(ctx context.Context, checkpoint uint64) ([]*Credit, uint64, error) {
+	head, err := s.client.GetSlot(ctx, s.Commitment)
+	if err != nil {
+		return nil, checkpoint, xerrors.Errorf("get slot: %w", err)
+	}
+	if head <= checkpoint {
+		return nil, checkpoint, nil
+	}
+
+	seen := map[solana.Signature]bool{}
+	var credits []*Credit
+	var addresses []solana.PublicKey
+	for _, addr := range s.Watched() {
+		accounts, err := s.tokenAccounts(ctx, addr)
+		if err != nil {
+			return nil, checkpoint, err
+		}
+		addresses = append(append(addresses, addr), accounts...)
+	}
+	for _, addr := range addresses {
+		signatures, err := s.signatures(ctx, addr, checkpoint, head)
+		if err != nil {
+			return nil, checkpoint, err
+		}
+		for _, sig := range signatures {
+			if seen[sig.Signature] {
+				continue
+			}
+			seen[sig.Signature] = true
+			c, err := s.ScanTransaction(ctx, sig.Signature)
+			if err != nil {
+				return nil, checkpoint, err
+			}
+			credits = append(credits, c...)
+		}
+	}
+	sortCredits(credits)
+	return credits, head, nil
+}
+
+// ScanBlocks scans every block in the slots after checkpoint up to and including end,
+// and returns their credits together with the next checkpoint.
+// On error the credits and checkpoint of the blocks scanned so far are returned.
+func (s *Scanner) ScanBlocks(ctx context.Context, checkpoint uint64, end uint64) ([]*Credit, uint64, error) {
+	if end <= checkpoint {
+		return nil, checkpoint, nil
+	}
+	slots, err := s.client.GetBlocks(ctx, checkpoint+1, &end, s.Commitment)
+	if err != nil {
+		return nil, checkpoint, xerrors.Errorf("get blocks: %w", err)
+	}
+	var credits []*Credit
+	for _, slot := range slots {
+		c, err := s.ScanBlock(ctx, slot)
+		if err != nil {
+			return credits, checkpoint, err
+		}
+		credits = append(credits, c...)
+		checkpoint = slot
+	}
+	return credits, end, nil
+}
+
+// ScanBlock returns the credits of every successful transaction in the block, legacy and v0.
+func (s *Scanner) ScanBlock(ctx context.Context, slot uint64) ([]*Credit, error) {
+	block, err := s.getBlock(ctx, slot)
+	if err != nil {
+		return nil, xerrors.Errorf("get block %d: %w", slot, err)
+	}
+	var credits []*Credit
+	for _, vt := range block.Transactions {
+		if vt.Meta == nil || vt.Meta.Err != nil {
+			continue
+		}
+		tx, meta, err := vt.decode()
+		if err != nil {
+			return nil, xerrors.Errorf("decode transaction in block %d: %w", slot, err)
+		}
+		credits = append(credits, s.ParseCredits(slot, block.BlockTime, tx, meta)...)
+	}
+	return credits, nil
+}
+
+// ScanTransaction fetches a legacy or v0 transaction and returns its credits, none if it failed.
+func (s *Scanner) ScanTransaction(ctx context.Context, signature solana.Signature) ([]*Credit, error) {
+	out, err := s.getTransaction(ctx, signature)
+	if err != nil {
+		return nil, xerrors.Errorf("get transaction %s: %w", signature, err)
+	}
+	if out.Meta == nil || out.Meta.Err != nil || out.Transaction == nil {
+		return nil, nil
+	}
+	tx, meta, err := out.decode()
+	if err != nil {
+		return nil, xerrors.Errorf("decode transaction %s: %w", signature, err)
+	}
+	return s.ParseCredits(out.Slot, out.BlockTime, tx, meta), nil
+}
+
+// ParseCredits extracts the system transfers and SPL token transfer/transferChecked instructions,
+// top-level and inner, that credit a watched address. Memos of the transaction are attached to every credit.
+// The account keys of a v0 transaction must include the addresses loaded from lookup tables, as ScanTransaction does.
+// SPL transfers whose mint is not known, from the instruction or the token balances of meta, are skipped.
+func (s *Scanner) ParseCredits(slot uint64, blockTime *solana.UnixTimeSeconds, tx *solana.Transaction, meta *rpc.TransactionMeta) []*Credit {
+	if meta != nil && meta.Err != nil {
+		return nil
+	}
+	p := &creditParser{
+		scanner:       s,
+		tx:            tx,
+		tokenBalances: map[uint16]rpc.TokenBalance{},
+	}
+	if meta != nil {
+		for _, b := range meta.PreTokenBalances {
+			p.tokenBalances[b.AccountIndex] = b
+		}
+		for _, b := range meta.PostTokenBalances {
+			p.tokenBalances[b.AccountIndex] = b
+		}
+	}
+
+	memo := joinMemos(ParseMemos(tx, meta))
+	var credits []*Credit
+	add := func(c *Credit, index int, inner int) {
+		if c == nil {
+			return
+		}
+		c.Memo = memo
+		c.Signature = tx.Signatures[0]
+		c.Slot = slot
+		c.BlockTime = blockTime
+		c.Instruction = index
+		c.Inner = inner
+		credits = append(credits, c)
+	}
+	for i, inst := range tx.Message.Instructions {
+		add(p.parse(inst), i, -1)
+	}
+	if meta != nil {
+		for _, inner := range meta.InnerInstructions {
+			for j, inst := range inner.Instructions {
+				add(p.parse(inst), int(inner.Index), j)
+			}
+		}
+	}
+	return credits
+}
+
+type creditParser struct {
+	scanner       *Scanner
+	tx            *solana.Transaction
+	tokenBalances map[uint16]rpc.TokenBalance
+}
+
+func (p *creditParser) account(inst solana.CompiledInstruction, i int) (solana.PublicKey, uint16, bool) {
+	if i >= len(inst.Accounts) || int(inst.Accounts[i]) >= len(p.tx.Message.AccountKeys) {
+		return solana.PublicKey{}, 0, false
+	}
+	index := inst.Accounts[i]
+	return p.tx.Message.AccountKeys[index], index, true
+}
+
+func (p *creditParser) parse(inst solana.CompiledInstruction) *Credit {
+	program, err := p.tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case program.Equals(solana.SystemProgramID):
+		return p.parseSystem(inst)
+	case program.Equals(solana.TokenProgramID), program.Equals(Token2022ProgramID):
+		return p.parseToken(inst)
+	}
+	return nil
+}
+
+func (p *creditParser) parseSystem(inst solana.CompiledInstruction) *Credit {
+	data := inst.Data
+	if len(data) < 12 {
+		return nil
+	}
+	var from, to solana.PublicKey
+	var ok bool
+	switch binary.LittleEndian.Uint32(data) {
+	case systemInstructionTransfer:
+		from, _, ok = p.account(inst, 0)
+		if ok {
+			to, _, ok = p.account(inst, 1)
+		}
+	case systemInstructionTransferWithSeed:
+		from, _, ok = p.account(inst, 0)
+		if ok {
+			to, _, ok = p.account(inst, 2)
+		}
+	}
+	if !ok || !p.scanner.isWatched(to) {
+		return nil
+	}
+	return &Credit{
+		Address:  to,
+		Account:  to,
+		Amount:   new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[4:12])),
+		Decimals: 9,
+		Sender:   from,
+	}
+}
+
+func (p *creditParser) parseToken(inst solana.CompiledInstruction) *Credit {
+	data := inst.Data
+	if len(data) < 9 {
+		return nil
+	}
+	var destination, authority solana.PublicKey
+	var sourceIndex, destinationIndex uint16
+	var ok bool
+	c := &Credit{Amount: new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[1:9]))}
+	switch data[0] {
+	case tokenInstructionTransfer:
+		_, sourceIndex, ok = p.account(inst, 0)
+		if ok {
+			destination, destinationIndex, ok = p.account(inst, 1)
+		}
+		if ok {
+			authority, _, ok = p.account(inst, 2)
+		}
+	case tokenInstructionTransferChecked:
+		if len(data) < 10 {
+			return nil
+		}
+		c.Decimals = data[9]
+		_, sourceIndex, ok = p.account(inst, 0)
+		if ok {
+			c.Mint, _, ok = p.account(inst, 1)
+		}
+		if ok {
+			destination, destinationIndex, ok = p.account(inst, 2)
+		}
+		if ok {
+			authority, _, ok = p.account(inst, 3)
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	c.Account = destination
+	c.Address = destination
+	if b, found := p.tokenBalances[destinationIndex]; found {
+		c.Mint = b.Mint
+		if b.UiTokenAmount != nil {
+			c.Decimals = b.UiTokenAmount.Decimals
+		}
+		if b.Owner != nil {
+			c.Address = *b.Owner
+		}
+	}
+	if !p.scanner.isWatched(c.Address) && !p.scanner.isWatched(destination) {
+		return nil
+	}
+	if c.Mint.IsZero() {
+		// a plain transfer without token balances, it must not pass for SOL
+		return nil
+	}
+	c.Sender = authority
+	if b, found := p.tokenBalances[sourceIndex]; found && b.Owner != nil {
+		c.Sender = *b.Owner
+	}
+	return c
+}
+
+// tokenAccounts returns the Token and Token-2022 accounts owned by owner. A transfer to a token account that
+// already exists does not reference its owner, so it only shows in the history of the token account.
+func (s *Scanner) tokenAccounts(ctx context.Context, owner solana.PublicKey) ([]solana.PublicKey, error) {
+	var accounts []solana.PublicKey
+	var zero uint64
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, Token2022ProgramID} {
+		program := program
+		out, err := s.client.GetTokenAccountsByOwner(ctx, owner, &rpc.GetTokenAccountsConfig{ProgramId: &program}, &rpc.GetTokenAccountsOpts{
+			Commitment: s.Commitment,
+			Encoding:   solana.EncodingBase64,
+			// only the addresses are needed
+			DataSlice: &rpc.DataSlice{Offset: &zero, Length: &zero},
+		})
+		if err != nil && program.Equals(Token2022ProgramID) && strings.Contains(err.Error(), "unrecognized Token program id") {
+			// nodes older than Token-2022 reject its program id
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("get token accounts of %s: %w", owner, err)
+		}
+		for _, account := range out.Value {
+			accounts = append(accounts, account.Pubkey)
+		}
+	}
+	return accounts, nil
+}
+
+// signatures returns the successful signatures of addr in slots (from, to], oldest first.
+func (s *Scanner) signatures(ctx context.Context, addr solana.PublicKey, from uint64, to uint64) ([]*rpc.TransactionSignature, error) {
+	var result []*rpc.TransactionSignature
+	limit := s.PageSize
+	opts := &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Commitment: s.Commitment,
+	}
+	for {
+		page, err := s.client.GetSignaturesForAddressWithOpts(ctx, addr, opts)
+		if err != nil {
+			return nil, xerrors.Errorf("get signatures for %s: %w", addr, err)
+		}
+		for _, sig := range page {
+			if sig.Slot <= from {
+				reverseSignatures(result)
+				return result, nil
+			}
+			if sig.Slot <= to && sig.Err == nil {
+				result = append(result, sig)
+			}
+		}
+		if len(page) < limit {
+			reverseSignatures(result)
+			return result, nil
+		}
+		opts.Before = page[len(page)-1].Signature
+	}
+}
+
+func reverseSignatures(list []*rpc.TransactionSignature) {
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+}
+
+func sortCredits(credits []*Credit) {
+	sort.SliceStable(credits, func(i, j int) bool {
+		return credits[i].Slot < credits[j].Slot
+	})
+}
@@ -0,0 +1,264 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScanner_ParseCredits(t *testing.T) {
+	sender, _ := solana.NewRandomPrivateKey()
+	deposit, _ := solana.NewRandomPrivateKey()
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	source, _ := FindAssociatedTokenAddress(sender.PublicKey(), mint)
+	destination, _ := FindAssociatedTokenAddress(deposit.PublicKey(), mint)
+
+	memo, err := NewMemoInstruction("user:42", sender.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(5000, sender.PublicKey(), deposit.PublicKey()).Build(),
+			token.NewTransferCheckedInstruction(1230000, 6, source, mint, destination, sender.PublicKey(), nil).Build(),
+			memo,
+		},
+		solana.Hash{},
+		solana.TransactionPayer(sender.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signatures = []solana.Signature{{1}}
+
+	owner := deposit.PublicKey()
+	meta := &rpc.TransactionMeta{}
+	for i, key := range tx.Message.AccountKeys {
+		if key.Equals(destination) {
+			meta.PostTokenBalances = append(meta.PostTokenBalances, rpc.TokenBalance{
+				AccountIndex:  uint16(i),
+				Owner:         &owner,
+				Mint:          mint,
+				UiTokenAmount: &rpc.UiTokenAmount{Amount: "1230000", Decimals: 6},
+			})
+		}
+	}
+
+	scanner := NewScanner(nil, deposit.PublicKey())
+	credits := scanner.ParseCredits(100, nil, tx, meta)
+	if len(credits) != 2 {
+		t.Fatalf("expected 2 credits, got %d", len(credits))
+	}
+	if !credits[0].IsNative() || credits[0].Amount.Uint64() != 5000 || !credits[0].Sender.Equals(sender.PublicKey()) {
+		t.Errorf("wrong SOL credit: %+v", credits[0])
+	}
+	if credits[0].Memo != "user:42" || credits[1].Memo != "user:42" {
+		t.Errorf("wrong memo %q", credits[0].Memo)
+	}
+	c := credits[1]
+	if !c.Mint.Equals(mint) || c.Amount.Uint64() != 1230000 || c.Decimals != 6 || !c.Address.Equals(owner) || !c.Account.Equals(destination) {
+		t.Errorf("wrong token credit: %+v", c)
+	}
+
+	meta.Err = map[string]interface{}{"InstructionError": []interface{}{0, "Custom"}}
+	if len(scanner.ParseCredits(100, nil, tx, meta)) != 0 {
+		t.Error("failed transaction should give no credits")
+	}
+}
+
+func TestScanner_UnknownMint(t *testing.T) {
+	sender, _ := solana.NewRandomPrivateKey()
+	deposit, _ := solana.NewRandomPrivateKey()
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	source, _ := FindAssociatedTokenAddress(sender.PublicKey(), mint)
+	destination, _ := FindAssociatedTokenAddress(deposit.PublicKey(), mint)
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{token.NewTransferInstruction(1000, source, destination, sender.PublicKey(), nil).Build()},
+		solana.Hash{},
+		solana.TransactionPayer(sender.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signatures = []solana.Signature{{1}}
+
+	// a plain transfer names no mint, without token balances it cannot be told apart from any other token
+	scanner := NewScanner(nil, destination)
+	if credits := scanner.ParseCredits(100, nil, tx, &rpc.TransactionMeta{}); len(credits) != 0 {
+		t.Errorf("expected no credit, got %+v", credits[0])
+	}
+}
+
+func TestScanner_ScanV0Transaction(t *testing.T) {
+	sender, _ := solana.NewRandomPrivateKey()
+	deposit, _ := solana.NewRandomPrivateKey()
+	table, _ := solana.NewRandomPrivateKey()
+
+	// the deposit address is loaded from a lookup table, at index 2 after the two static keys
+	transfer := system.NewTransferInstruction(7000, sender.PublicKey(), deposit.PublicKey()).Build()
+	data, err := transfer.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := solana.Message{
+		Header:       solana.MessageHeader{NumRequiredSignatures: 1, NumReadonlyUnsignedAccounts: 1},
+		AccountKeys:  []solana.PublicKey{sender.PublicKey(), solana.SystemProgramID},
+		Instructions: []solana.CompiledInstruction{{ProgramIDIndex: 1, Accounts: []uint16{0, 2}, Data: data}},
+	}
+	legacy, err := message.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := solana.Signature{9}
+	raw := append([]byte{1}, signature[:]...)
+	raw = append(raw, 0x80)
+	raw = append(raw, legacy...)
+	raw = append(raw, 1)
+	raw = append(raw, table.PublicKey().Bytes()...)
+	raw = append(raw, 1, 0, 0)
+
+	tx := map[string]interface{}{
+		"slot":        7,
+		"blockTime":   nil,
+		"transaction": []string{base64.StdEncoding.EncodeToString(raw), "base64"},
+		"meta": map[string]interface{}{
+			"err":             nil,
+			"loadedAddresses": map[string]interface{}{"writable": []string{deposit.PublicKey().String()}, "readonly": []string{}},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		opts := req.Params[1].(map[string]interface{})
+		if v, ok := opts["maxSupportedTransactionVersion"]; !ok || v != 0.0 {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{
+				"code": -32015, "message": "Transaction version (0) is not supported",
+			}})
+			return
+		}
+		var result interface{} = tx
+		if req.Method == "getBlock" {
+			result = map[string]interface{}{"blockTime": nil, "transactions": []interface{}{tx}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	scanner := NewScanner(NewClient(server.URL), deposit.PublicKey())
+	credits, err := scanner.ScanTransaction(context.Background(), signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credits) != 1 || !credits[0].IsNative() || credits[0].Amount.Uint64() != 7000 || credits[0].Slot != 7 ||
+		!credits[0].Address.Equals(deposit.PublicKey()) || !credits[0].Sender.Equals(sender.PublicKey()) {
+		t.Fatalf("wrong credits %+v", credits)
+	}
+	credits, err = scanner.ScanBlock(context.Background(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credits) != 1 || credits[0].Signature != signature {
+		t.Fatalf("wrong block credits %+v", credits)
+	}
+}
+
+func TestScanner_ScanTokenAccount(t *testing.T) {
+	sender, _ := solana.NewRandomPrivateKey()
+	deposit, _ := solana.NewRandomPrivateKey()
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	source, _ := FindAssociatedTokenAddress(sender.PublicKey(), mint)
+	destination, _ := FindAssociatedTokenAddress(deposit.PublicKey(), mint)
+
+	// a transfer into an existing token account does not reference the deposit wallet
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{token.NewTransferInstruction(4200, source, destination, sender.PublicKey(), nil).Build()},
+		solana.Hash{},
+		solana.TransactionPayer(sender.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := solana.Signature{3}
+	tx.Signatures = []solana.Signature{signature}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := deposit.PublicKey()
+	var balances []rpc.TokenBalance
+	for i, key := range tx.Message.AccountKeys {
+		if key.Equals(deposit.PublicKey()) {
+			t.Fatal("the transaction references the deposit wallet")
+		}
+		if key.Equals(destination) {
+			balances = append(balances, rpc.TokenBalance{
+				AccountIndex:  uint16(i),
+				Owner:         &owner,
+				Mint:          mint,
+				UiTokenAmount: &rpc.UiTokenAmount{Amount: "4200", Decimals: 6},
+			})
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{}   `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		switch req.Method {
+		case "getSlot":
+			result = 10
+		case "getTokenAccountsByOwner":
+			value := []interface{}{}
+			conf := req.Params[1].(map[string]interface{})
+			if req.Params[0] == owner.String() && conf["programId"] == solana.TokenProgramID.String() {
+				value = append(value, map[string]interface{}{
+					"pubkey":  destination.String(),
+					"account": map[string]interface{}{"lamports": 2039280, "owner": solana.TokenProgramID.String(), "data": []string{"", "base64"}},
+				})
+			}
+			result = map[string]interface{}{"context": map[string]interface{}{"slot": 10}, "value": value}
+		case "getSignaturesForAddress":
+			list := []interface{}{}
+			if req.Params[0] == destination.String() {
+				list = append(list, map[string]interface{}{"signature": signature.String(), "slot": 5, "err": nil})
+			}
+			result = list
+		case "getTransaction":
+			result = map[string]interface{}{
+				"slot":        5,
+				"blockTime":   nil,
+				"transaction": []string{base64.StdEncoding.EncodeToString(raw), "base64"},
+				"meta":        map[string]interface{}{"err": nil, "postTokenBalances": balances},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	credits, checkpoint, err := NewScanner(NewClient(server.URL), owner).Scan(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint != 10 {
+		t.Errorf("wrong checkpoint %d", checkpoint)
+	}
+	if len(credits) != 1 || credits[0].Signature != signature || credits[0].Amount.Uint64() != 4200 || !credits[0].Mint.Equals(mint) ||
+		!credits[0].Address.Equals(owner) || !credits[0].Account.Equals(destination) {
+		t.Fatalf("wrong credits %+v", credits)
+	}
+}
@@ -0,0 +1,107 @@
+package solana
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"github.com/gagliardetto/solana-go"
+	"github.com/icodeface/chain-kit/remotesigner"
+	"golang.org/x/xerrors"
+)
+
+// Signer holds the ed25519 key of an account, in memory, in an HSM or in a remote service.
+type Signer interface {
+	PublicKey() solana.PublicKey
+	SignMessage(ctx context.Context, message []byte) (solana.Signature, error)
+}
+
+// KeySigner signs with a private key in memory.
+type KeySigner struct {
+	key solana.PrivateKey
+}
+
+func NewKeySigner(key solana.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (s *KeySigner) PublicKey() solana.PublicKey {
+	return s.key.PublicKey()
+}
+
+func (s *KeySigner) SignMessage(ctx context.Context, message []byte) (solana.Signature, error) {
+	return s.key.Sign(message)
+}
+
+// RemoteSigner signs with a key of a remote signer, see package remotesigner.
+type RemoteSigner struct {
+	client    *remotesigner.Client
+	id        string
+	publicKey solana.PublicKey
+}
+
+// NewRemoteSigner fetches the public key of the ed25519 key id from the remote signer.
+func NewRemoteSigner(ctx context.Context, client *remotesigner.Client, id string) (*RemoteSigner, error) {
+	keyType, key, err := client.PublicKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if keyType != remotesigner.KeyTypeEd25519 || len(key) != ed25519.PublicKeySize {
+		return nil, xerrors.Errorf("key %s is not an ed25519 key", id)
+	}
+	return &RemoteSigner{client: client, id: id, publicKey: solana.PublicKeyFromBytes(key)}, nil
+}
+
+func (s *RemoteSigner) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+// SignMessage signs remotely and verifies the signature with the public key.
+func (s *RemoteSigner) SignMessage(ctx context.Context, message []byte) (solana.Signature, error) {
+	sig, err := s.client.Sign(ctx, s.id, message)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(s.publicKey[:], message, sig) {
+		return solana.Signature{}, xerrors.New("signature does not match the remote key")
+	}
+	return solana.SignatureFromBytes(sig), nil
+}
+
+// ErrNoPrivateKey is returned when exporting the key of an account created by AccountFromSigner.
+var ErrNoPrivateKey = errors.New("account has no private key")
+
+// AccountFromSigner creates an account signing with signer. Its PrivateKey is nil.
+func AccountFromSigner(signer Signer) *Account {
+	return &Account{
+		Address: signer.PublicKey().String(),
+		Signer:  signer,
+	}
+}
+
+func (account *Account) signer() Signer {
+	if account.Signer != nil {
+		return account.Signer
+	}
+	return NewKeySigner(account.PrivateKey)
+}
+
+// signTransaction signs the transaction message, whose only required signer must be the account.
+func (account *Account) signTransaction(ctx context.Context, tx *solana.Transaction) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return xerrors.Errorf("encode message: %w", err)
+	}
+	pub := account.PublicKey()
+	signers := tx.Message.AccountKeys[:tx.Message.Header.NumRequiredSignatures]
+	signatures := make([]solana.Signature, len(signers))
+	for i, key := range signers {
+		if !key.Equals(pub) {
+			return xerrors.Errorf("signer key %s is not the account", key)
+		}
+		if signatures[i], err = account.signer().SignMessage(ctx, message); err != nil {
+			return err
+		}
+	}
+	tx.Signatures = signatures
+	return nil
+}
@@ -0,0 +1,62 @@
+package solana
+
+import (
+	"context"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/icodeface/chain-kit/remotesigner"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	key, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := AccountFromPrivateKey(key)
+	server := remotesigner.NewServer([]byte("secret"))
+	server.AddEd25519Key("hot", []byte(key))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	signer, err := NewRemoteSigner(context.Background(), remotesigner.NewClient(ts.URL, []byte("secret")), "hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := AccountFromSigner(signer)
+	if remote.Address != account.Address {
+		t.Fatalf("wrong remote address %s", remote.Address)
+	}
+
+	sig, err := remote.SignOffchainMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyOffchainMessage(account.Address, "hello", sig); err != nil || !ok {
+		t.Errorf("invalid remote signature: %v", err)
+	}
+
+	to, _ := solana.NewRandomPrivateKey()
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(1000, remote.PublicKey(), to.PublicKey()).Build()},
+		solana.Hash{1},
+		solana.TransactionPayer(remote.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.signTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Errorf("invalid transaction signature: %v", err)
+	}
+
+	// the context of the caller reaches the remote signer
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := remote.SignOffchainMessageContext(cancelled, "hello"); err == nil {
+		t.Error("expected a cancelled context error")
+	}
+}
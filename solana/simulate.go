@@ -0,0 +1,141 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/xerrors"
+	"math/big"
+)
+
+// BalanceChange is the SOL balance of an account before and after a simulated transaction.
+type BalanceChange struct {
+	Address solana.PublicKey
+	Before  *big.Int
+	After   *big.Int
+}
+
+// Delta returns After - Before in lamports.
+func (c *BalanceChange) Delta() *big.Int {
+	return new(big.Int).Sub(c.After, c.Before)
+}
+
+// Preview is the outcome of a simulated transaction.
+type Preview struct {
+	// Err is the transaction error reported by the simulation, nil if it would succeed.
+	Err           interface{}
+	Logs          []string
+	UnitsConsumed uint64
+	// Fee is the fee in lamports charged for the transaction message.
+	Fee *big.Int
+	// BalanceChanges holds the writable accounts of the transaction.
+	BalanceChanges []*BalanceChange
+}
+
+// Failed reports whether the transaction would fail.
+func (p *Preview) Failed() bool {
+	return p.Err != nil
+}
+
+// Simulate runs the transaction with simulateTransaction without sending it, and returns its logs,
+// compute units, balance changes of the writable accounts and the exact fee from getFeeForMessage.
+// The transaction must be signed, e.g. built with Account.NewTransaction or Account.NewTransferTransaction.
+func Simulate(rpcClient *Client, tx *solana.Transaction) (*Preview, error) {
+	ctx := context.TODO()
+	var writable []solana.PublicKey
+	for _, key := range tx.Message.AccountKeys {
+		if tx.IsWritable(key) {
+			writable = append(writable, key)
+		}
+	}
+
+	before, err := rpcClient.GetMultipleAccountsWithOpts(ctx, writable, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: rpc.CommitmentProcessed,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("get accounts: %w", err)
+	}
+
+	sim, err := simulateTransaction(ctx, rpcClient, tx, writable)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := GetFee(rpcClient, &tx.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &Preview{
+		Err:  sim.Err,
+		Logs: sim.Logs,
+		Fee:  fee,
+	}
+	if sim.UnitsConsumed != nil {
+		preview.UnitsConsumed = *sim.UnitsConsumed
+	}
+	for i, addr := range writable {
+		change := &BalanceChange{
+			Address: addr,
+			Before:  new(big.Int),
+			After:   new(big.Int),
+		}
+		if before != nil && i < len(before.Value) && before.Value[i] != nil {
+			change.Before.SetUint64(before.Value[i].Lamports)
+		}
+		if i < len(sim.Accounts) && sim.Accounts[i] != nil {
+			change.After.SetUint64(sim.Accounts[i].Lamports)
+		} else {
+			// accounts are not returned when the simulation fails
+			change.After.Set(change.Before)
+		}
+		preview.BalanceChanges = append(preview.BalanceChanges, change)
+	}
+	return preview, nil
+}
+
+// GetFee returns the fee in lamports the cluster charges for the message at its recent blockhash.
+func GetFee(rpcClient *Client, message *solana.Message) (*big.Int, error) {
+	out, err := rpcClient.GetFeeForMessage(context.TODO(), message.ToBase64(), rpc.CommitmentProcessed)
+	if err != nil {
+		return nil, xerrors.Errorf("get fee for message: %w", err)
+	}
+	if out.Value == nil {
+		return nil, xerrors.New("blockhash of the message has expired")
+	}
+	return new(big.Int).SetUint64(*out.Value), nil
+}
+
+// simulateTransaction calls simulateTransaction directly, rpc.Client.SimulateTransaction of solana-go v1.4
+// decodes the result without its context wrapper and always returns an empty response.
+func simulateTransaction(ctx context.Context, rpcClient *Client, tx *solana.Transaction, accounts []solana.PublicKey) (*rpc.SimulateTransactionResponse, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, xerrors.Errorf("encode transaction: %w", err)
+	}
+	var out struct {
+		rpc.RPCContext
+		Value *rpc.SimulateTransactionResponse `json:"value"`
+	}
+	err = rpcClient.RPCCallForInto(ctx, &out, "simulateTransaction", []interface{}{
+		base64.StdEncoding.EncodeToString(data),
+		rpc.M{
+			"encoding":   solana.EncodingBase64,
+			"sigVerify":  true,
+			"commitment": rpc.CommitmentProcessed,
+			"accounts": rpc.M{
+				"encoding":  solana.EncodingBase64,
+				"addresses": accounts,
+			},
+		},
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("simulate transaction: %w", err)
+	}
+	if out.Value == nil {
+		return nil, xerrors.New("simulate transaction: empty result")
+	}
+	return out.Value, nil
+}
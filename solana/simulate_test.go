@@ -0,0 +1,76 @@
+package solana
+
+import (
+	"encoding/json"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		switch req.Method {
+		case "getMultipleAccounts":
+			result = map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1},
+				"value": []interface{}{
+					map[string]interface{}{"lamports": 1000000, "owner": solana.SystemProgramID.String(), "data": []string{"", "base64"}},
+					nil,
+				},
+			}
+		case "simulateTransaction":
+			result = map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1},
+				"value": map[string]interface{}{
+					"err":           nil,
+					"logs":          []string{"Program 11111111111111111111111111111111 success"},
+					"unitsConsumed": 150,
+					"accounts": []interface{}{
+						map[string]interface{}{"lamports": 895000, "owner": solana.SystemProgramID.String(), "data": []string{"", "base64"}},
+						map[string]interface{}{"lamports": 100000, "owner": solana.SystemProgramID.String(), "data": []string{"", "base64"}},
+					},
+				},
+			}
+		case "getFeeForMessage":
+			result = map[string]interface{}{"context": map[string]interface{}{"slot": 1}, "value": 5000}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	from, _ := solana.NewRandomPrivateKey()
+	to, _ := solana.NewRandomPrivateKey()
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{system.NewTransferInstruction(100000, from.PublicKey(), to.PublicKey()).Build()},
+		solana.Hash{},
+		solana.TransactionPayer(from.PublicKey()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &from }); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := Simulate(NewClient(server.URL), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Failed() || preview.UnitsConsumed != 150 || preview.Fee.Int64() != 5000 || len(preview.Logs) != 1 {
+		t.Errorf("wrong preview: %+v", preview)
+	}
+	if len(preview.BalanceChanges) != 2 {
+		t.Fatalf("expected 2 balance changes, got %d", len(preview.BalanceChanges))
+	}
+	if preview.BalanceChanges[0].Delta().Int64() != -105000 || preview.BalanceChanges[1].Delta().Int64() != 100000 {
+		t.Errorf("wrong balance changes: %v %v", preview.BalanceChanges[0].Delta(), preview.BalanceChanges[1].Delta())
+	}
+}
@@ -0,0 +1,331 @@
+package solana
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/xerrors"
+	"math/big"
+)
+
+var (
+	StakeProgramID = solana.MustPublicKeyFromBase58("Stake11111111111111111111111111111111111111")
+	StakeConfigID  = solana.MustPublicKeyFromBase58("StakeConfig11111111111111111111111111111111")
+)
+
+// StakeAccountSize is the data size of a stake account.
+const StakeAccountSize = 200
+
+const (
+	stakeInstructionInitialize = 0
+	stakeInstructionDelegate   = 2
+	stakeInstructionSplit      = 3
+	stakeInstructionWithdraw   = 4
+	stakeInstructionDeactivate = 5
+	stakeInstructionMerge      = 7
+)
+
+// StakeAccountAddress returns the address of the stake account created by base with seed.
+func StakeAccountAddress(base solana.PublicKey, seed string) (solana.PublicKey, error) {
+	return solana.CreateWithSeed(base, seed, StakeProgramID)
+}
+
+// CreateStakeAccount creates and initializes a stake account derived from the account address and seed,
+// funded with amount lamports. The account is both stake and withdraw authority.
+func (account *Account) CreateStakeAccount(rpcClient *Client, seed string, amount *big.Int) (solana.PublicKey, *solana.Signature, error) {
+	stake, tx, err := account.NewCreateStakeAccountTransaction(rpcClient, seed, amount)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+	sig, err := SendTransaction(rpcClient, tx)
+	return stake, sig, err
+}
+
+// NewCreateStakeAccountTransaction builds and signs the transaction sent by CreateStakeAccount.
+func (account *Account) NewCreateStakeAccountTransaction(rpcClient *Client, seed string, amount *big.Int) (solana.PublicKey, *solana.Transaction, error) {
+	stake, err := StakeAccountAddress(account.PublicKey(), seed)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+	rent, err := rpcClient.GetMinimumBalanceForRentExemption(context.TODO(), StakeAccountSize, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.PublicKey{}, nil, xerrors.Errorf("get rent exemption: %w", err)
+	}
+	if !amount.IsUint64() || amount.Uint64() <= rent {
+		return solana.PublicKey{}, nil, xerrors.Errorf("amount must be greater than the rent exemption of %d lamports", rent)
+	}
+
+	tx, err := account.NewTransaction(rpcClient,
+		system.NewCreateAccountWithSeedInstruction(
+			account.PublicKey(),
+			seed,
+			amount.Uint64(),
+			StakeAccountSize,
+			StakeProgramID,
+			account.PublicKey(),
+			stake,
+			account.PublicKey(),
+		).Build(),
+		newStakeInitializeInstruction(stake, account.PublicKey(), account.PublicKey()),
+	)
+	return stake, tx, err
+}
+
+// DelegateStake delegates the stake account to a validator vote account.
+func (account *Account) DelegateStake(rpcClient *Client, stake solana.PublicKey, vote solana.PublicKey) (*solana.Signature, error) {
+	tx, err := account.NewDelegateStakeTransaction(rpcClient, stake, vote)
+	if err != nil {
+		return nil, err
+	}
+	return SendTransaction(rpcClient, tx)
+}
+
+// NewDelegateStakeTransaction builds and signs the transaction sent by DelegateStake.
+func (account *Account) NewDelegateStakeTransaction(rpcClient *Client, stake solana.PublicKey, vote solana.PublicKey) (*solana.Transaction, error) {
+	return account.NewTransaction(rpcClient, newStakeInstruction(
+		stakeInstructionDelegate,
+		nil,
+		solana.Meta(stake).WRITE(),
+		solana.Meta(vote),
+		solana.Meta(solana.SysVarClockPubkey),
+		solana.Meta(solana.SysVarStakeHistoryPubkey),
+		solana.Meta(StakeConfigID),
+		solana.Meta(account.PublicKey()).SIGNER(),
+	))
+}
+
+// DeactivateStake starts the cooldown of the stake account. It can be withdrawn once inactive.
+func (account *Account) DeactivateStake(rpcClient *Client, stake solana.PublicKey) (*solana.Signature, error) {
+	tx, err := account.NewDeactivateStakeTransaction(rpcClient, stake)
+	if err != nil {
+		return nil, err
+	}
+	return SendTransaction(rpcClient, tx)
+}
+
+// NewDeactivateStakeTransaction builds and signs the transaction sent by DeactivateStake.
+func (account *Account) NewDeactivateStakeTransaction(rpcClient *Client, stake solana.PublicKey) (*solana.Transaction, error) {
+	return account.NewTransaction(rpcClient, newStakeInstruction(
+		stakeInstructionDeactivate,
+		nil,
+		solana.Meta(stake).WRITE(),
+		solana.Meta(solana.SysVarClockPubkey),
+		solana.Meta(account.PublicKey()).SIGNER(),
+	))
+}
+
+// SplitStake moves amount lamports of the stake account into a new stake account derived from seed.
+// The new account is funded with its rent exemption by the account, as required by the stake program.
+func (account *Account) SplitStake(rpcClient *Client, stake solana.PublicKey, seed string, amount *big.Int) (solana.PublicKey, *solana.Signature, error) {
+	split, tx, err := account.NewSplitStakeTransaction(rpcClient, stake, seed, amount)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+	sig, err := SendTransaction(rpcClient, tx)
+	return split, sig, err
+}
+
+// NewSplitStakeTransaction builds and signs the transaction sent by SplitStake.
+func (account *Account) NewSplitStakeTransaction(rpcClient *Client, stake solana.PublicKey, seed string, amount *big.Int) (solana.PublicKey, *solana.Transaction, error) {
+	split, err := StakeAccountAddress(account.PublicKey(), seed)
+	if err != nil {
+		return solana.PublicKey{}, nil, err
+	}
+	if !amount.IsUint64() || amount.Sign() <= 0 {
+		return solana.PublicKey{}, nil, errors.New("invalid value")
+	}
+	rent, err := rpcClient.GetMinimumBalanceForRentExemption(context.TODO(), StakeAccountSize, rpc.CommitmentFinalized)
+	if err != nil {
+		return solana.PublicKey{}, nil, xerrors.Errorf("get rent exemption: %w", err)
+	}
+
+	tx, err := account.NewTransaction(rpcClient,
+		system.NewCreateAccountWithSeedInstruction(
+			account.PublicKey(),
+			seed,
+			rent,
+			StakeAccountSize,
+			StakeProgramID,
+			account.PublicKey(),
+			split,
+			account.PublicKey(),
+		).Build(),
+		newStakeInstruction(
+			stakeInstructionSplit,
+			uint64Bytes(amount.Uint64()),
+			solana.Meta(stake).WRITE(),
+			solana.Meta(split).WRITE(),
+			solana.Meta(account.PublicKey()).SIGNER(),
+		),
+	)
+	return split, tx, err
+}
+
+// MergeStake merges source into destination. Both must share authorities and activation state.
+func (account *Account) MergeStake(rpcClient *Client, destination solana.PublicKey, source solana.PublicKey) (*solana.Signature, error) {
+	tx, err := account.NewMergeStakeTransaction(rpcClient, destination, source)
+	if err != nil {
+		return nil, err
+	}
+	return SendTransaction(rpcClient, tx)
+}
+
+// NewMergeStakeTransaction builds and signs the transaction sent by MergeStake.
+func (account *Account) NewMergeStakeTransaction(rpcClient *Client, destination solana.PublicKey, source solana.PublicKey) (*solana.Transaction, error) {
+	return account.NewTransaction(rpcClient, newStakeInstruction(
+		stakeInstructionMerge,
+		nil,
+		solana.Meta(destination).WRITE(),
+		solana.Meta(source).WRITE(),
+		solana.Meta(solana.SysVarClockPubkey),
+		solana.Meta(solana.SysVarStakeHistoryPubkey),
+		solana.Meta(account.PublicKey()).SIGNER(),
+	))
+}
+
+// WithdrawStake withdraws amount lamports from an inactive stake account to the address to.
+func (account *Account) WithdrawStake(rpcClient *Client, stake solana.PublicKey, to string, amount *big.Int) (*solana.Signature, error) {
+	tx, err := account.NewWithdrawStakeTransaction(rpcClient, stake, to, amount)
+	if err != nil {
+		return nil, err
+	}
+	return SendTransaction(rpcClient, tx)
+}
+
+// NewWithdrawStakeTransaction builds and signs the transaction sent by WithdrawStake.
+func (account *Account) NewWithdrawStakeTransaction(rpcClient *Client, stake solana.PublicKey, to string, amount *big.Int) (*solana.Transaction, error) {
+	accountTo, err := solana.PublicKeyFromBase58(to)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsUint64() || amount.Sign() <= 0 {
+		return nil, errors.New("invalid value")
+	}
+	return account.NewTransaction(rpcClient, newStakeInstruction(
+		stakeInstructionWithdraw,
+		uint64Bytes(amount.Uint64()),
+		solana.Meta(stake).WRITE(),
+		solana.Meta(accountTo).WRITE(),
+		solana.Meta(solana.SysVarClockPubkey),
+		solana.Meta(solana.SysVarStakeHistoryPubkey),
+		solana.Meta(account.PublicKey()).SIGNER(),
+	))
+}
+
+// GetStakeActivation returns the activation state (active, inactive, activating, deactivating) of the stake account
+// at epoch, the current epoch when nil. It is computed from the delegation of the stake account and the StakeHistory
+// sysvar like the stake program does, the getStakeActivation RPC method is gone from current nodes.
+func GetStakeActivation(rpcClient *Client, stake solana.PublicKey, epoch *uint64) (*rpc.GetStakeActivationResult, error) {
+	ctx := context.TODO()
+	if epoch == nil {
+		info, err := rpcClient.GetEpochInfo(ctx, rpc.CommitmentFinalized)
+		if err != nil {
+			return nil, xerrors.Errorf("get epoch info: %w", err)
+		}
+		epoch = &info.Epoch
+	}
+	out, err := rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{stake, solana.SysVarStakeHistoryPubkey}, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: rpc.CommitmentFinalized,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("get stake accounts: %w", err)
+	}
+	if len(out.Value) != 2 || out.Value[0] == nil || out.Value[1] == nil {
+		return nil, xerrors.Errorf("stake account %s not found", stake)
+	}
+	account, historyAccount := out.Value[0], out.Value[1]
+	if !account.Owner.Equals(StakeProgramID) {
+		return nil, xerrors.Errorf("%s is not a stake account", stake)
+	}
+	state, err := parseStakeState(account.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+	history, err := parseStakeHistory(historyAccount.Data.GetBinary())
+	if err != nil {
+		return nil, err
+	}
+
+	var status stakeStatus
+	if state.delegation != nil {
+		status = state.delegation.status(*epoch, history)
+	}
+	result := &rpc.GetStakeActivationResult{Active: status.effective}
+	switch {
+	case status.deactivating > 0:
+		result.State = rpc.ActivationStateDeactivating
+	case status.activating > 0:
+		result.State = rpc.ActivationStateActivating
+	case status.effective > 0:
+		result.State = rpc.ActivationStateActive
+	default:
+		result.State = rpc.ActivationStateInactive
+	}
+	result.Inactive = saturatingSub(saturatingSub(account.Lamports, status.effective), state.rentExemptReserve)
+	return result, nil
+}
+
+// StakeReward is the inflation reward credited to a stake account for an epoch.
+type StakeReward struct {
+	Epoch       uint64
+	Amount      *big.Int
+	PostBalance *big.Int
+	Commission  *uint8
+}
+
+// GetStakeRewards returns the rewards of the stake account for each epoch in [fromEpoch, toEpoch].
+// Epochs without a reward are left out.
+func GetStakeRewards(rpcClient *Client, stake solana.PublicKey, fromEpoch uint64, toEpoch uint64) ([]*StakeReward, error) {
+	var rewards []*StakeReward
+	for epoch := fromEpoch; epoch <= toEpoch; epoch++ {
+		e := epoch
+		out, err := rpcClient.GetInflationReward(context.TODO(), []solana.PublicKey{stake}, &rpc.GetInflationRewardOpts{
+			Commitment: rpc.CommitmentFinalized,
+			Epoch:      &e,
+		})
+		if err != nil {
+			return nil, xerrors.Errorf("get inflation reward for epoch %d: %w", epoch, err)
+		}
+		if len(out) == 0 || out[0] == nil {
+			continue
+		}
+		rewards = append(rewards, &StakeReward{
+			Epoch:       out[0].Epoch,
+			Amount:      new(big.Int).SetUint64(out[0].Amount),
+			PostBalance: new(big.Int).SetUint64(out[0].PostBalance),
+			Commission:  out[0].Commission,
+		})
+	}
+	return rewards, nil
+}
+
+func newStakeInitializeInstruction(stake solana.PublicKey, staker solana.PublicKey, withdrawer solana.PublicKey) solana.Instruction {
+	// Authorized { staker, withdrawer } followed by an empty Lockup { unix_timestamp, epoch, custodian }
+	data := make([]byte, 0, 64+8+8+32)
+	data = append(data, staker[:]...)
+	data = append(data, withdrawer[:]...)
+	data = append(data, make([]byte, 8+8+32)...)
+	return newStakeInstruction(
+		stakeInstructionInitialize,
+		data,
+		solana.Meta(stake).WRITE(),
+		solana.Meta(solana.SysVarRentPubkey),
+	)
+}
+
+func newStakeInstruction(index uint32, params []byte, accounts ...*solana.AccountMeta) solana.Instruction {
+	data := make([]byte, 4, 4+len(params))
+	binary.LittleEndian.PutUint32(data, index)
+	data = append(data, params...)
+	return solana.NewInstruction(StakeProgramID, accounts, data)
+}
+
+func uint64Bytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
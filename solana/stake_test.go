@@ -0,0 +1,154 @@
+package solana
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStakeInitializeInstruction(t *testing.T) {
+	authority := solana.MustPublicKeyFromBase58("6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy76a")
+	stake, err := StakeAccountAddress(authority, "stake:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inst := newStakeInitializeInstruction(stake, authority, authority)
+	data, err := inst.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4+64+48 {
+		t.Fatalf("wrong data length %d", len(data))
+	}
+	if !bytes.Equal(data[:4], []byte{0, 0, 0, 0}) || !bytes.Equal(data[4:36], authority[:]) || !bytes.Equal(data[36:68], authority[:]) {
+		t.Error("wrong initialize data")
+	}
+	if !inst.ProgramID().Equals(StakeProgramID) || !inst.Accounts()[0].PublicKey.Equals(stake) || !inst.Accounts()[0].IsWritable {
+		t.Error("wrong initialize accounts")
+	}
+
+	split := newStakeInstruction(stakeInstructionSplit, uint64Bytes(1000))
+	data, _ = split.Data()
+	if !bytes.Equal(data, []byte{3, 0, 0, 0, 0xe8, 0x03, 0, 0, 0, 0, 0, 0}) {
+		t.Errorf("wrong split data %x", data)
+	}
+}
+
+func TestStakeActivation(t *testing.T) {
+	activating := &stakeDelegation{stake: 1000, activationEpoch: 10, deactivationEpoch: math.MaxUint64}
+	deactivating := &stakeDelegation{stake: 1000, activationEpoch: 1, deactivationEpoch: 20}
+	history := map[uint64]stakeHistoryEntry{
+		10: {effective: 10000, activating: 5000},
+		11: {effective: 10900, activating: 4100},
+		20: {effective: 10000, deactivating: 5000},
+	}
+	for _, c := range []struct {
+		delegation *stakeDelegation
+		epoch      uint64
+		expected   stakeStatus
+	}{
+		{activating, 9, stakeStatus{}},
+		{activating, 10, stakeStatus{activating: 1000}},
+		// 1000/5000 of 9% of the cluster effective stake
+		{activating, 11, stakeStatus{effective: 180, activating: 820}},
+		{activating, 12, stakeStatus{effective: 376, activating: 624}},
+		// without history for epoch 12 the warmup stops where it is
+		{activating, 13, stakeStatus{effective: 376, activating: 624}},
+		{deactivating, 19, stakeStatus{effective: 1000}},
+		{deactivating, 20, stakeStatus{effective: 1000, deactivating: 1000}},
+		{deactivating, 21, stakeStatus{effective: 820, deactivating: 820}},
+		{&stakeDelegation{stake: 1000, activationEpoch: 5, deactivationEpoch: 5}, 6, stakeStatus{}},
+	} {
+		if status := c.delegation.status(c.epoch, history); status != c.expected {
+			t.Errorf("epoch %d: expected %+v, got %+v", c.epoch, c.expected, status)
+		}
+	}
+}
+
+func TestGetStakeActivation(t *testing.T) {
+	const reserve = 2282880
+	stake := solana.MustPublicKeyFromBase58("6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy76a")
+	data := make([]byte, StakeAccountSize)
+	binary.LittleEndian.PutUint32(data, stakeStateStake)
+	binary.LittleEndian.PutUint64(data[stakeMetaOffset:], reserve)
+	binary.LittleEndian.PutUint64(data[stakeDelegationOffset+32:], 1000)
+	binary.LittleEndian.PutUint64(data[stakeDelegationOffset+40:], 10)
+	binary.LittleEndian.PutUint64(data[stakeDelegationOffset+48:], math.MaxUint64)
+	history := make([]byte, 8, 8+stakeHistoryEntrySize)
+	binary.LittleEndian.PutUint64(history, 1)
+	for _, v := range []uint64{10, 10000, 5000, 0} {
+		history = append(history, uint64Bytes(v)...)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     interface{} `json:"id"`
+			Method string      `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result interface{}
+		switch req.Method {
+		case "getEpochInfo":
+			result = map[string]interface{}{"epoch": 11, "absoluteSlot": 1, "blockHeight": 1, "slotIndex": 0, "slotsInEpoch": 432000}
+		case "getMultipleAccounts":
+			result = map[string]interface{}{
+				"context": map[string]interface{}{"slot": 1},
+				"value": []interface{}{
+					map[string]interface{}{"lamports": reserve + 1050, "owner": StakeProgramID.String(), "data": []string{base64.StdEncoding.EncodeToString(data), "base64"}},
+					map[string]interface{}{"lamports": 1, "owner": "Sysvar1111111111111111111111111111111111111", "data": []string{base64.StdEncoding.EncodeToString(history), "base64"}},
+				},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer server.Close()
+
+	activation, err := GetStakeActivation(NewClient(server.URL), stake, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activation.State != rpc.ActivationStateActivating || activation.Active != 180 || activation.Inactive != 870 {
+		t.Errorf("wrong activation %+v", activation)
+	}
+}
+
+func TestNewDelegateStakeTransaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID interface{} `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{
+			"context": map[string]interface{}{"slot": 1},
+			"value":   map[string]interface{}{"blockhash": solana.Hash{1}.String(), "feeCalculator": map[string]interface{}{"lamportsPerSignature": 5000}},
+		}})
+	}))
+	defer server.Close()
+
+	key, _ := solana.NewRandomPrivateKey()
+	account := AccountFromPrivateKey(key)
+	stake, _ := StakeAccountAddress(account.PublicKey(), "stake:0")
+	vote := solana.MustPublicKeyFromBase58("6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy76a")
+	tx, err := account.NewDelegateStakeTransaction(NewClient(server.URL), stake, vote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.VerifySignatures(); err != nil {
+		t.Fatal(err)
+	}
+	inst := tx.Message.Instructions[0]
+	if program, _ := tx.ResolveProgramIDIndex(inst.ProgramIDIndex); !program.Equals(StakeProgramID) || inst.Data[0] != stakeInstructionDelegate {
+		t.Errorf("wrong delegate instruction %+v", inst)
+	}
+	if !tx.Message.AccountKeys[inst.Accounts[0]].Equals(stake) || !tx.Message.AccountKeys[inst.Accounts[1]].Equals(vote) {
+		t.Error("wrong delegate accounts")
+	}
+}
@@ -0,0 +1,176 @@
+package solana
+
+import (
+	"encoding/binary"
+	"golang.org/x/xerrors"
+	"math"
+)
+
+// StakeWarmupCooldownRate is the share of the cluster effective stake that can activate or deactivate per epoch.
+// It is the rate since the reduce_stake_warmup_cooldown feature, activations at epochs before the feature used 0.25.
+var StakeWarmupCooldownRate = 0.09
+
+const (
+	stakeStateUninitialized = 0
+	stakeStateInitialized   = 1
+	stakeStateStake         = 2
+
+	// stake state tag (4) | meta: rent exempt reserve (8), authorized (64), lockup (48) | delegation
+	stakeMetaOffset       = 4
+	stakeDelegationOffset = stakeMetaOffset + 8 + 64 + 48
+	stakeDelegationSize   = 32 + 8 + 8 + 8 + 8
+	stakeHistoryEntrySize = 8 + 24
+)
+
+type stakeState struct {
+	rentExemptReserve uint64
+	// delegation is nil for initialized but undelegated accounts
+	delegation *stakeDelegation
+}
+
+type stakeDelegation struct {
+	stake             uint64
+	activationEpoch   uint64
+	deactivationEpoch uint64
+}
+
+type stakeHistoryEntry struct {
+	effective    uint64
+	activating   uint64
+	deactivating uint64
+}
+
+type stakeStatus struct {
+	effective    uint64
+	activating   uint64
+	deactivating uint64
+}
+
+func parseStakeState(data []byte) (*stakeState, error) {
+	if len(data) < stakeDelegationOffset {
+		return nil, xerrors.Errorf("invalid stake account size %d", len(data))
+	}
+	state := &stakeState{rentExemptReserve: binary.LittleEndian.Uint64(data[stakeMetaOffset:])}
+	switch binary.LittleEndian.Uint32(data) {
+	case stakeStateInitialized:
+		return state, nil
+	case stakeStateStake:
+		if len(data) < stakeDelegationOffset+stakeDelegationSize {
+			return nil, xerrors.Errorf("invalid stake account size %d", len(data))
+		}
+		d := data[stakeDelegationOffset:]
+		state.delegation = &stakeDelegation{
+			stake:             binary.LittleEndian.Uint64(d[32:]),
+			activationEpoch:   binary.LittleEndian.Uint64(d[40:]),
+			deactivationEpoch: binary.LittleEndian.Uint64(d[48:]),
+		}
+		return state, nil
+	case stakeStateUninitialized:
+		return nil, xerrors.New("stake account is not initialized")
+	default:
+		return nil, xerrors.New("not a stake account")
+	}
+}
+
+// parseStakeHistory decodes the StakeHistory sysvar, a list of (epoch, entry) for the recent epochs.
+func parseStakeHistory(data []byte) (map[uint64]stakeHistoryEntry, error) {
+	if len(data) < 8 {
+		return nil, xerrors.New("invalid stake history")
+	}
+	count := binary.LittleEndian.Uint64(data)
+	if count > uint64(len(data)-8)/stakeHistoryEntrySize {
+		return nil, xerrors.Errorf("invalid stake history length %d", count)
+	}
+	history := make(map[uint64]stakeHistoryEntry, count)
+	for i := uint64(0); i < count; i++ {
+		e := data[8+i*stakeHistoryEntrySize:]
+		history[binary.LittleEndian.Uint64(e)] = stakeHistoryEntry{
+			effective:    binary.LittleEndian.Uint64(e[8:]),
+			activating:   binary.LittleEndian.Uint64(e[16:]),
+			deactivating: binary.LittleEndian.Uint64(e[24:]),
+		}
+	}
+	return history, nil
+}
+
+// status follows stake_activating_and_deactivating of the stake program: stake warms up and cools down by its
+// share of the cluster stake activating or deactivating in the previous epoch.
+func (d *stakeDelegation) status(epoch uint64, history map[uint64]stakeHistoryEntry) stakeStatus {
+	effective, activating := d.stakeAndActivating(epoch, history)
+	switch {
+	case epoch < d.deactivationEpoch:
+		return stakeStatus{effective: effective, activating: activating}
+	case epoch == d.deactivationEpoch:
+		return stakeStatus{effective: effective, deactivating: effective}
+	}
+	prev, ok := history[d.deactivationEpoch]
+	if !ok {
+		return stakeStatus{}
+	}
+	current := effective
+	for prevEpoch := d.deactivationEpoch; prev.deactivating > 0; {
+		currentEpoch := prevEpoch + 1
+		weight := float64(current) / float64(prev.deactivating)
+		current = saturatingSub(current, maxUint64(uint64(weight*(float64(prev.effective)*StakeWarmupCooldownRate)), 1))
+		if current == 0 || currentEpoch >= epoch {
+			break
+		}
+		if prev, ok = history[currentEpoch]; !ok {
+			break
+		}
+		prevEpoch = currentEpoch
+	}
+	return stakeStatus{effective: current, deactivating: current}
+}
+
+func (d *stakeDelegation) stakeAndActivating(epoch uint64, history map[uint64]stakeHistoryEntry) (uint64, uint64) {
+	switch {
+	case d.activationEpoch == math.MaxUint64:
+		// bootstrap stake, active from genesis
+		return d.stake, 0
+	case d.activationEpoch == d.deactivationEpoch:
+		// deactivated before it activated
+		return 0, 0
+	case epoch == d.activationEpoch:
+		return 0, d.stake
+	case epoch < d.activationEpoch:
+		return 0, 0
+	}
+	prev, ok := history[d.activationEpoch]
+	if !ok {
+		// older than the history, fully active
+		return d.stake, 0
+	}
+	var current uint64
+	for prevEpoch := d.activationEpoch; prev.activating > 0; {
+		currentEpoch := prevEpoch + 1
+		weight := float64(d.stake-current) / float64(prev.activating)
+		current += maxUint64(uint64(weight*(float64(prev.effective)*StakeWarmupCooldownRate)), 1)
+		if current >= d.stake {
+			current = d.stake
+			break
+		}
+		if currentEpoch >= epoch || currentEpoch >= d.deactivationEpoch {
+			break
+		}
+		if prev, ok = history[currentEpoch]; !ok {
+			break
+		}
+		prevEpoch = currentEpoch
+	}
+	return current, d.stake - current
+}
+
+func saturatingSub(a uint64, b uint64) uint64 {
+	if a < b {
+		return 0
+	}
+	return a - b
+}
+
+func maxUint64(a uint64, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
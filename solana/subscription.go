@@ -0,0 +1,290 @@
+package solana
+
+import (
+	"context"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"golang.org/x/xerrors"
+	"sync"
+	"time"
+)
+
+type (
+	AccountNotification   = ws.AccountResult
+	LogsNotification      = ws.LogResult
+	SignatureNotification = ws.SignatureResult
+	SlotNotification      = ws.SlotResult
+)
+
+// WSClient wraps the websocket RPC client and keeps subscriptions alive across disconnects.
+// All subscriptions share a single connection. When it drops, every subscription reconnects
+// and subscribes again after ReconnectDelay, until its context is cancelled.
+type WSClient struct {
+	endpoint  string
+	rpcClient *Client
+
+	// ReconnectDelay is the pause between reconnect attempts.
+	ReconnectDelay time.Duration
+	// OnError, if set, is called with connection and subscription errors that trigger a reconnect.
+	OnError func(err error)
+
+	lock sync.Mutex
+	conn *ws.Client
+}
+
+// NewWSClient creates a subscription client for the websocket endpoint, e.g. rpc.MainNetBeta_WS.
+// rpcClient is optional. When set, signature subscriptions check the signature status over HTTP after
+// every (re)subscribe, so confirmations that happened while disconnected are not missed.
+func NewWSClient(endpoint string, rpcClient *Client) *WSClient {
+	return &WSClient{
+		endpoint:       endpoint,
+		rpcClient:      rpcClient,
+		ReconnectDelay: 3 * time.Second,
+	}
+}
+
+// Close closes the underlying connection. Running subscriptions reconnect unless their contexts are cancelled.
+func (c *WSClient) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// SubscribeAccount streams the account data every time it changes.
+// The channel is closed when ctx is cancelled.
+func (c *WSClient) SubscribeAccount(ctx context.Context, account solana.PublicKey, commitment rpc.CommitmentType) <-chan *AccountNotification {
+	ch := make(chan *AccountNotification, 16)
+	go func() {
+		defer close(ch)
+		c.keepSubscribed(ctx, func(conn *ws.Client) (func() (bool, error), func(), error) {
+			sub, err := conn.AccountSubscribe(account, commitment)
+			if err != nil {
+				return nil, nil, err
+			}
+			return func() (bool, error) {
+				res, err := sub.Recv()
+				if res == nil {
+					return true, err
+				}
+				select {
+				case ch <- res:
+				case <-ctx.Done():
+				}
+				return false, nil
+			}, sub.Unsubscribe, nil
+		})
+	}()
+	return ch
+}
+
+// SubscribeLogs streams the logs of every transaction mentioning address.
+// The channel is closed when ctx is cancelled.
+func (c *WSClient) SubscribeLogs(ctx context.Context, address solana.PublicKey, commitment rpc.CommitmentType) <-chan *LogsNotification {
+	ch := make(chan *LogsNotification, 16)
+	go func() {
+		defer close(ch)
+		c.keepSubscribed(ctx, func(conn *ws.Client) (func() (bool, error), func(), error) {
+			sub, err := conn.LogsSubscribeMentions(address, commitment)
+			if err != nil {
+				return nil, nil, err
+			}
+			return func() (bool, error) {
+				res, err := sub.Recv()
+				if res == nil {
+					return true, err
+				}
+				select {
+				case ch <- res:
+				case <-ctx.Done():
+				}
+				return false, nil
+			}, sub.Unsubscribe, nil
+		})
+	}()
+	return ch
+}
+
+// SubscribeSignature waits for the transaction to reach the commitment level.
+// A single notification is delivered, after which the channel is closed. It is also closed when ctx is cancelled.
+func (c *WSClient) SubscribeSignature(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) <-chan *SignatureNotification {
+	ch := make(chan *SignatureNotification, 1)
+	go func() {
+		defer close(ch)
+		c.keepSubscribed(ctx, func(conn *ws.Client) (func() (bool, error), func(), error) {
+			sub, err := conn.SignatureSubscribe(signature, commitment)
+			if err != nil {
+				return nil, nil, err
+			}
+			checked := false
+			return func() (bool, error) {
+				var res *SignatureNotification
+				var err error
+				// a failing status check is retried on its own, the shared connection is fine
+				for !checked {
+					if res, err = c.signatureStatus(ctx, signature, commitment); err == nil {
+						checked = true
+						break
+					}
+					c.reportError(ctx, err)
+					select {
+					case <-ctx.Done():
+						return true, nil
+					case <-time.After(c.ReconnectDelay):
+					}
+				}
+				if res == nil {
+					if res, err = sub.Recv(); res == nil {
+						return true, err
+					}
+				}
+				select {
+				case ch <- res:
+				case <-ctx.Done():
+				}
+				return true, nil
+			}, sub.Unsubscribe, nil
+		})
+	}()
+	return ch
+}
+
+// SubscribeSlot streams every slot processed by the node.
+// The channel is closed when ctx is cancelled.
+func (c *WSClient) SubscribeSlot(ctx context.Context) <-chan *SlotNotification {
+	ch := make(chan *SlotNotification, 16)
+	go func() {
+		defer close(ch)
+		c.keepSubscribed(ctx, func(conn *ws.Client) (func() (bool, error), func(), error) {
+			sub, err := conn.SlotSubscribe()
+			if err != nil {
+				return nil, nil, err
+			}
+			return func() (bool, error) {
+				res, err := sub.Recv()
+				if res == nil {
+					return true, err
+				}
+				select {
+				case ch <- res:
+				case <-ctx.Done():
+				}
+				return false, nil
+			}, sub.Unsubscribe, nil
+		})
+	}()
+	return ch
+}
+
+// signatureStatus returns a notification if the signature already reached the commitment level, nil otherwise.
+func (c *WSClient) signatureStatus(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) (*SignatureNotification, error) {
+	if c.rpcClient == nil {
+		return nil, nil
+	}
+	out, err := c.rpcClient.GetSignatureStatuses(ctx, true, signature)
+	if err != nil {
+		return nil, xerrors.Errorf("get signature status: %w", err)
+	}
+	if len(out.Value) == 0 || out.Value[0] == nil || !reachedCommitment(out.Value[0].ConfirmationStatus, commitment) {
+		return nil, nil
+	}
+	res := &SignatureNotification{}
+	res.Context.Slot = out.Value[0].Slot
+	res.Value.Err = out.Value[0].Err
+	return res, nil
+}
+
+func reachedCommitment(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	switch commitment {
+	case rpc.CommitmentFinalized, rpc.CommitmentMax, rpc.CommitmentRoot:
+		return status == rpc.ConfirmationStatusFinalized
+	case rpc.CommitmentConfirmed, rpc.CommitmentSingleGossip:
+		return status == rpc.ConfirmationStatusConfirmed || status == rpc.ConfirmationStatusFinalized
+	default:
+		return status != ""
+	}
+}
+
+// keepSubscribed subscribes on the shared connection and drains notifications with recv until recv reports done
+// or ctx is cancelled. Errors drop the connection and subscribe again after ReconnectDelay.
+func (c *WSClient) keepSubscribed(ctx context.Context, subscribe func(conn *ws.Client) (recv func() (bool, error), unsubscribe func(), err error)) {
+	var conn *ws.Client
+	for ctx.Err() == nil {
+		err := c.runSubscription(ctx, &conn, subscribe)
+		if err == nil {
+			return
+		}
+		c.reportError(ctx, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.ReconnectDelay):
+		}
+	}
+}
+
+func (c *WSClient) runSubscription(ctx context.Context, conn **ws.Client, subscribe func(conn *ws.Client) (func() (bool, error), func(), error)) error {
+	var err error
+	*conn, err = c.connect(ctx, *conn)
+	if err != nil {
+		return xerrors.Errorf("connect %s: %w", c.endpoint, err)
+	}
+	recv, unsubscribe, err := subscribe(*conn)
+	if err != nil {
+		return xerrors.Errorf("subscribe: %w", err)
+	}
+	// unsubscribing twice, or after the connection dropped, is a no-op
+	defer unsubscribe()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// makes the pending Recv return with a nil result and a nil error
+			unsubscribe()
+		case <-stop:
+		}
+	}()
+
+	for {
+		done, err := recv()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+func (c *WSClient) reportError(ctx context.Context, err error) {
+	if c.OnError != nil && ctx.Err() == nil {
+		c.OnError(err)
+	}
+}
+
+// connect returns the shared connection, dialing a new one if there is none or if stale is the current one.
+func (c *WSClient) connect(ctx context.Context, stale *ws.Client) (*ws.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn != nil && c.conn != stale {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	conn, err := ws.Connect(ctx, c.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
@@ -0,0 +1,232 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// wsServer is a minimal websocket RPC node counting connections and (un)subscriptions.
+type wsServer struct {
+	*httptest.Server
+
+	lock         sync.Mutex
+	conns        []*wsConn
+	nextID       uint64
+	subscribed   map[string]int
+	unsubscribed map[string]int
+}
+
+type wsConn struct {
+	lock sync.Mutex
+	conn *websocket.Conn
+	subs map[string]uint64
+}
+
+func newWSServer(t *testing.T) *wsServer {
+	s := &wsServer{subscribed: map[string]int{}, unsubscribed: map[string]int{}}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		c := &wsConn{conn: conn, subs: map[string]uint64{}}
+		s.lock.Lock()
+		s.conns = append(s.conns, c)
+		s.lock.Unlock()
+		for {
+			var req struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.lock.Lock()
+			if strings.HasSuffix(req.Method, "Unsubscribe") {
+				s.unsubscribed[req.Method]++
+				s.lock.Unlock()
+				continue
+			}
+			s.nextID++
+			id := s.nextID
+			s.subscribed[req.Method]++
+			s.lock.Unlock()
+			c.lock.Lock()
+			c.subs[req.Method] = id
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": id})
+			c.lock.Unlock()
+		}
+	}))
+	return s
+}
+
+func (s *wsServer) endpoint() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func (s *wsServer) connections() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.conns)
+}
+
+func (s *wsServer) count(counts map[string]int, method string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return counts[method]
+}
+
+// notify sends result to the subscriptions made with method on the last connection.
+func (s *wsServer) notify(method string, notification string, result interface{}) {
+	s.lock.Lock()
+	c := s.conns[len(s.conns)-1]
+	s.lock.Unlock()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_ = c.conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  notification,
+		"params":  map[string]interface{}{"result": result, "subscription": c.subs[method]},
+	})
+}
+
+// drop closes every connection.
+func (s *wsServer) drop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, c := range s.conns {
+		_ = c.conn.Close()
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func recvSlot(t *testing.T, ch <-chan *SlotNotification) *SlotNotification {
+	select {
+	case res := <-ch:
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("no slot notification")
+		return nil
+	}
+}
+
+func TestWSClientReconnect(t *testing.T) {
+	server := newWSServer(t)
+	defer server.Close()
+	client := NewWSClient(server.endpoint(), nil)
+	client.ReconnectDelay = 10 * time.Millisecond
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	slots := client.SubscribeSlot(ctx)
+	waitFor(t, func() bool { return server.count(server.subscribed, "slotSubscribe") == 1 })
+	server.notify("slotSubscribe", "slotNotification", map[string]interface{}{"parent": 0, "root": 0, "slot": 1})
+	if res := recvSlot(t, slots); res == nil || res.Slot != 1 {
+		t.Fatalf("wrong notification %+v", res)
+	}
+
+	// the subscription reconnects and subscribes again
+	server.drop()
+	waitFor(t, func() bool { return server.count(server.subscribed, "slotSubscribe") == 2 })
+	if server.connections() != 2 {
+		t.Errorf("expected 2 connections, got %d", server.connections())
+	}
+	server.notify("slotSubscribe", "slotNotification", map[string]interface{}{"parent": 1, "root": 0, "slot": 2})
+	if res := recvSlot(t, slots); res == nil || res.Slot != 2 {
+		t.Fatalf("wrong notification %+v", res)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-slots:
+		for ok {
+			_, ok = <-slots
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+	waitFor(t, func() bool { return server.count(server.unsubscribed, "slotUnsubscribe") == 1 })
+}
+
+func TestWSClientSignatureStatusRetry(t *testing.T) {
+	var lock sync.Mutex
+	calls := 0
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID interface{} `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		lock.Lock()
+		calls++
+		first := calls == 1
+		lock.Unlock()
+		if first {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{
+			"context": map[string]interface{}{"slot": 5},
+			"value":   []interface{}{map[string]interface{}{"slot": 5, "confirmations": nil, "err": nil, "confirmationStatus": "finalized"}},
+		}})
+	}))
+	defer rpcServer.Close()
+	server := newWSServer(t)
+	defer server.Close()
+
+	client := NewWSClient(server.endpoint(), NewClient(rpcServer.URL))
+	client.ReconnectDelay = 10 * time.Millisecond
+	var errs []error
+	client.OnError = func(err error) { errs = append(errs, err) }
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	slots := client.SubscribeSlot(ctx)
+	waitFor(t, func() bool { return server.count(server.subscribed, "slotSubscribe") == 1 })
+
+	signatures := client.SubscribeSignature(ctx, solana.Signature{1}, rpc.CommitmentFinalized)
+	select {
+	case res := <-signatures:
+		if res == nil || res.Context.Slot != 5 {
+			t.Fatalf("wrong notification %+v", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no signature notification")
+	}
+	if _, ok := <-signatures; ok {
+		t.Error("signature channel not closed")
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the status error to be reported once, got %v", errs)
+	}
+	waitFor(t, func() bool { return server.count(server.unsubscribed, "signatureUnsubscribe") == 1 })
+
+	// the failed status check kept the shared connection and the slot subscription
+	if server.connections() != 1 || server.count(server.subscribed, "slotSubscribe") != 1 {
+		t.Fatalf("connection dropped: %d connections", server.connections())
+	}
+	server.notify("slotSubscribe", "slotNotification", map[string]interface{}{"parent": 2, "root": 0, "slot": 3})
+	if res := recvSlot(t, slots); res == nil || res.Slot != 3 {
+		t.Fatalf("wrong notification %+v", res)
+	}
+}
@@ -1,7 +1,9 @@
 package solana
 
 import (
+	"github.com/gagliardetto/solana-go"
 	"github.com/shopspring/decimal"
+	"golang.org/x/xerrors"
 	"math/big"
 )
 
@@ -16,3 +18,39 @@ func FromSOL(v decimal.Decimal) *big.Int {
 	r := v.Mul(decimal.NewFromInt(10).Pow(decimal.NewFromInt(9)))
 	return r.BigInt()
 }
+
+// ValidateAddress checks that addr is a base58 encoded 32-byte public key.
+// Program derived addresses are valid too, use IsOnCurve to tell them apart from wallet addresses.
+func ValidateAddress(addr string) bool {
+	_, err := solana.PublicKeyFromBase58(addr)
+	return err == nil
+}
+
+// IsOnCurve reports whether addr lies on the ed25519 curve, i.e. whether it can be owned by a private key.
+// Off-curve addresses are program derived addresses, which only a program can sign for.
+func IsOnCurve(addr string) (bool, error) {
+	pub, err := solana.PublicKeyFromBase58(addr)
+	if err != nil {
+		return false, xerrors.Errorf("invalid address: %w", err)
+	}
+	return pub.IsOnCurve(), nil
+}
+
+// CreateProgramAddress derives a program address from seeds, which must already include the bump seed.
+func CreateProgramAddress(seeds [][]byte, programID solana.PublicKey) (solana.PublicKey, error) {
+	return solana.CreateProgramAddress(seeds, programID)
+}
+
+// FindProgramAddress finds the first off-curve program address for seeds and returns it with its bump seed.
+func FindProgramAddress(seeds [][]byte, programID solana.PublicKey) (solana.PublicKey, uint8, error) {
+	// solana-go appends the bump seed to the slice, don't let it touch the caller's backing array
+	s := make([][]byte, len(seeds), len(seeds)+1)
+	copy(s, seeds)
+	return solana.FindProgramAddress(s, programID)
+}
+
+// FindAssociatedTokenAddress derives the associated token account of owner for the SPL token mint.
+func FindAssociatedTokenAddress(owner solana.PublicKey, mint solana.PublicKey) (solana.PublicKey, error) {
+	addr, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	return addr, err
+}
@@ -0,0 +1,34 @@
+package solana
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	if !ValidateAddress("6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy76a") {
+		t.Error("valid address rejected")
+	}
+	for _, addr := range []string{"", "0x816A5f3ED3FB0DCb5C19A32C80cc9643fDB078EB", "6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy7"} {
+		if ValidateAddress(addr) {
+			t.Errorf("invalid address %q accepted", addr)
+		}
+	}
+}
+
+func TestFindAssociatedTokenAddress(t *testing.T) {
+	owner := solana.MustPublicKeyFromBase58("6hZqw492xow22UqCRW7NUZJzoPRzBTUdM2fqHN2oy76a")
+	usdc := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	ata, err := FindAssociatedTokenAddress(owner, usdc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onCurve, err := IsOnCurve(owner.String())
+	if err != nil || !onCurve {
+		t.Error("wallet address should be on curve")
+	}
+	onCurve, err = IsOnCurve(ata.String())
+	if err != nil || onCurve {
+		t.Error("associated token address should be off curve")
+	}
+}
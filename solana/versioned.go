@@ -0,0 +1,152 @@
+package solana
+
+import (
+	"context"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"golang.org/x/xerrors"
+)
+
+// maxTransactionVersion is the highest transaction version requested from the node.
+// Without it, nodes refuse to return v0 transactions and every block holding one.
+const maxTransactionVersion = 0
+
+// loadedAddresses are the accounts a v0 transaction loads from address lookup tables.
+type loadedAddresses struct {
+	Writable []solana.PublicKey `json:"writable"`
+	Readonly []solana.PublicKey `json:"readonly"`
+}
+
+type versionedMeta struct {
+	rpc.TransactionMeta
+	LoadedAddresses loadedAddresses `json:"loadedAddresses"`
+}
+
+type versionedTransaction struct {
+	Slot        uint64                  `json:"slot"`
+	BlockTime   *solana.UnixTimeSeconds `json:"blockTime"`
+	Transaction *solana.Data            `json:"transaction"`
+	Meta        *versionedMeta          `json:"meta"`
+}
+
+type versionedBlock struct {
+	BlockTime    *solana.UnixTimeSeconds `json:"blockTime"`
+	Transactions []versionedTransaction  `json:"transactions"`
+}
+
+func (s *Scanner) getTransaction(ctx context.Context, signature solana.Signature) (*versionedTransaction, error) {
+	var out *versionedTransaction
+	err := s.client.RPCCallForInto(ctx, &out, "getTransaction", []interface{}{signature, rpc.M{
+		"encoding":                       solana.EncodingBase64,
+		"commitment":                     s.Commitment,
+		"maxSupportedTransactionVersion": maxTransactionVersion,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, rpc.ErrNotFound
+	}
+	return out, nil
+}
+
+func (s *Scanner) getBlock(ctx context.Context, slot uint64) (*versionedBlock, error) {
+	var out *versionedBlock
+	err := s.client.RPCCallForInto(ctx, &out, "getBlock", []interface{}{slot, rpc.M{
+		"encoding":                       solana.EncodingBase64,
+		"transactionDetails":             rpc.TransactionDetailsFull,
+		"rewards":                        false,
+		"commitment":                     s.Commitment,
+		"maxSupportedTransactionVersion": maxTransactionVersion,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, rpc.ErrNotConfirmed
+	}
+	return out, nil
+}
+
+// decode returns the transaction and its meta. The account keys of a v0 transaction are its static keys followed by
+// the writable and the readonly addresses loaded from lookup tables, the order instructions index them in.
+func (vt *versionedTransaction) decode() (*solana.Transaction, *rpc.TransactionMeta, error) {
+	if vt.Transaction == nil {
+		return nil, nil, xerrors.New("missing transaction")
+	}
+	var meta *rpc.TransactionMeta
+	var loaded loadedAddresses
+	if vt.Meta != nil {
+		meta = &vt.Meta.TransactionMeta
+		loaded = vt.Meta.LoadedAddresses
+	}
+	tx, err := decodeVersionedTransaction(vt.Transaction.Content, loaded)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, meta, nil
+}
+
+func decodeVersionedTransaction(data []byte, loaded loadedAddresses) (*solana.Transaction, error) {
+	decoder := bin.NewBinDecoder(data)
+	tx := new(solana.Transaction)
+	numSignatures, err := bin.DecodeCompactU16LengthFromByteReader(decoder)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < numSignatures; i++ {
+		sig, err := decoder.ReadNBytes(64)
+		if err != nil {
+			return nil, err
+		}
+		tx.Signatures = append(tx.Signatures, solana.SignatureFromBytes(sig))
+	}
+
+	prefix, err := decoder.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if prefix[0]&0x80 == 0 {
+		// legacy message
+		if err := tx.Message.UnmarshalWithDecoder(decoder); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+	if version := prefix[0] & 0x7f; version != 0 {
+		return nil, xerrors.Errorf("unsupported transaction version %d", version)
+	}
+	_, _ = decoder.ReadByte()
+	// a v0 message is a legacy message followed by the address table lookups
+	if err := tx.Message.UnmarshalWithDecoder(decoder); err != nil {
+		return nil, err
+	}
+	numLookups, err := bin.DecodeCompactU16LengthFromByteReader(decoder)
+	if err != nil {
+		return nil, err
+	}
+	var writable, readonly int
+	for i := 0; i < numLookups; i++ {
+		if err := decoder.SkipBytes(32); err != nil {
+			return nil, err
+		}
+		for _, count := range []*int{&writable, &readonly} {
+			n, err := bin.DecodeCompactU16LengthFromByteReader(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if err := decoder.SkipBytes(uint(n)); err != nil {
+				return nil, err
+			}
+			*count += n
+		}
+	}
+	if writable != len(loaded.Writable) || readonly != len(loaded.Readonly) {
+		return nil, xerrors.Errorf("transaction loads %d writable and %d readonly addresses, meta has %d and %d",
+			writable, readonly, len(loaded.Writable), len(loaded.Readonly))
+	}
+	tx.Message.AccountKeys = append(tx.Message.AccountKeys, loaded.Writable...)
+	tx.Message.AccountKeys = append(tx.Message.AccountKeys, loaded.Readonly...)
+	return tx, nil
+}
@@ -3,11 +3,63 @@ package solana
 import (
 	"fmt"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/icodeface/chain-kit/shamir"
 	"github.com/icodeface/hdkeyring"
 	"math/big"
 	"testing"
 )
 
+func TestWalletWithPassphrase(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	for passphrase, expected := range map[string]string{
+		"":       "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", // Phantom
+		"TREZOR": "7zSmbu6gKkb6HB7UDPtHYjwCWuBHU1D4TpNZFm4sndQe",
+	} {
+		wallet, err := NewWalletWithPassphrase(mnemonic, passphrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account, err := wallet.DeriveAccount(DerivePath(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.Address != expected {
+			t.Errorf("wrong address %s with passphrase %q", account.Address, passphrase)
+		}
+	}
+}
+
+func TestWalletFromShares(t *testing.T) {
+	groups, err := shamir.SplitMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 1, []shamir.Group{{Threshold: 2, Count: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := NewWalletFromShares([]string{groups[0][2], groups[0][0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := wallet.DeriveAccount(DerivePath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Address != "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk" {
+		t.Errorf("wrong address %s", account.Address)
+	}
+
+	wallet, err = NewWalletFromSharesWithPassphrase([]string{groups[0][1], groups[0][2]}, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := NewWalletWithPassphrase("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _ = wallet.DeriveAccount(DerivePath(0))
+	if expectedAccount, _ := expected.DeriveAccount(DerivePath(0)); account.Address != expectedAccount.Address {
+		t.Errorf("passphrase ignored: %s", account.Address)
+	}
+}
+
 func TestNewWallet(t *testing.T) {
 	mnemonic := "tag volcano eight thank tide danger coast health above argue embrace heavy"
 	wallet, err := NewWallet(mnemonic)